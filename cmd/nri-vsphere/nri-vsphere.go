@@ -4,16 +4,20 @@
 package main
 
 import (
+	"crypto/x509"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 	"github.com/newrelic/nri-vsphere/internal/client"
 	"github.com/newrelic/nri-vsphere/internal/collect"
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/newrelic/nri-vsphere/internal/performance"
 	"github.com/newrelic/nri-vsphere/internal/process"
 	"github.com/newrelic/nri-vsphere/internal/tag"
@@ -30,6 +34,9 @@ var (
 	buildDate          = ""
 )
 
+// eventStoreTTL is how long the notified thresholds of a certificate or license no longer reported are kept
+const eventStoreTTL = 7 * 24 * time.Hour
+
 func main() {
 
 	cfg := config.New(integrationVersion)
@@ -56,10 +63,15 @@ func main() {
 
 	checkAndSanitizeConfig(cfg)
 
-	cfg.VMWareClient, err = client.New(cfg.Args.URL, cfg.Args.User, cfg.Args.Pass, cfg.Args.ValidateSSL)
+	// the run stats are created before the login so that the login calls and duration are reported
+	cfg.RunStats = model.NewRunStats()
+	loginStart := time.Now()
+	var endpointCertificate *x509.Certificate
+	cfg.VMWareClient, endpointCertificate, err = client.NewWithCertificate(cfg.Args.URL, cfg.Args.User, cfg.Args.Pass, cfg.Args.ValidateSSL, cfg.RunStats)
 	if err != nil {
 		cfg.Logrus.WithError(err).Fatal("failed to create client")
 	}
+	cfg.RunStats.RecordPhase("login", time.Since(loginStart))
 	defer func() {
 		err := client.Logout(cfg.VMWareClient)
 		if err != nil {
@@ -69,6 +81,9 @@ func main() {
 
 	cfg.IsVcenterAPIType = cfg.VMWareClient.ServiceContent.About.ApiType == "VirtualCenter"
 	cfg.Logrus.Debugf("API type:%s", cfg.VMWareClient.ServiceContent.About.ApiType)
+	if cfg.IsVcenterAPIType {
+		cfg.VcenterCertificate = endpointCertificate
+	}
 
 	if !cfg.IsVcenterAPIType && cfg.Args.EnableVsphereEvents {
 		cfg.Logrus.Warn("It is not possible to fetch events from the vCenter if the integration is pointing to an host")
@@ -77,9 +92,32 @@ func main() {
 		cfg.Logrus.Warn("It is not possible to fetch Tags from the vCenter if the integration is pointing to an host")
 	}
 
+	if !cfg.IsVcenterAPIType && cfg.Args.EnableVsan {
+		cfg.Logrus.Warn("It is not possible to fetch vSAN data from the vCenter if the integration is pointing to an host")
+	}
+
+	if !cfg.IsVcenterAPIType && cfg.Args.EnableApplianceHealth {
+		cfg.Logrus.Warn("It is not possible to fetch appliance health from the vCenter if the integration is pointing to an host")
+	}
+
+	if !cfg.IsVcenterAPIType && cfg.Args.EnableLicenses {
+		cfg.Logrus.Warn("It is not possible to fetch licenses from the vCenter if the integration is pointing to an host")
+	}
+
 	cfg.ViewManager = view.NewManager(cfg.VMWareClient.Client)
 
-	if cfg.TagCollectionEnabled() {
+	if cfg.Args.ValidatePerfFile {
+		if err := validatePerfFile(cfg); err != nil {
+			// log out before exiting since the deferred logout does not run on Fatal
+			if err := client.Logout(cfg.VMWareClient); err != nil {
+				cfg.Logrus.WithError(err).Error("error while logging out client")
+			}
+			cfg.Logrus.WithError(err).Fatal("performance metrics file validation failed")
+		}
+		return
+	}
+
+	if cfg.TagCollectionEnabled() || cfg.ApplianceHealthEnabled() {
 		restClient, err := client.NewRest(cfg.VMWareClient, cfg.Args.User, cfg.Args.Pass)
 		if err != nil {
 			cfg.Logrus.WithError(err).Fatal("failed to create client rest")
@@ -90,25 +128,48 @@ func main() {
 				cfg.Logrus.WithError(err).Error("error while logging out RestClient")
 			}
 		}()
+		cfg.RestClient = restClient
+	}
 
-		tm := tags.NewManager(restClient)
+	if cfg.TagCollectionEnabled() {
+		tm := tags.NewManager(cfg.RestClient)
 		tagCollector := tag.NewCollector(tm, cfg.Logrus)
-		if len(cfg.Args.IncludeTags) > 0 {
-			tagCollector.ParseFilterTagExpression(cfg.Args.IncludeTags)
+		if err := tagCollector.ParseFilterTagExpression(cfg.Args.IncludeTags); err != nil {
+			cfg.Logrus.WithError(err).Fatal("invalid include_tags expression")
+		}
+		if err := tagCollector.ParseExcludeTagExpression(cfg.Args.ExcludeTags); err != nil {
+			cfg.Logrus.WithError(err).Fatal("invalid exclude_tags expression")
+		}
+		if store, ttl := newTagCacheStore(cfg); store != nil {
+			tagCollector.SetCache(store, ttl, cfg.VMWareClient.ServiceContent.About.InstanceUuid)
 		}
 		cfg.TagCollector = tagCollector
 	}
 
+	if cfg.VsanCollectionEnabled() {
+		cfg.VsanClient, err = client.NewVsan(cfg.VMWareClient)
+		if err != nil {
+			cfg.Logrus.WithError(err).Fatal("failed to create vsan client")
+		}
+	}
+
 	if cfg.PerfMetricsCollectionEnabled() {
+		var store persist.Storer
+		if cfg.Args.PerfSampleMode != performance.SampleModeLatest {
+			store = newPerfCheckpointStore(cfg)
+		}
+		counterStore, counterCacheTTL := newPerfCounterStore(cfg)
 		perfCollector, err := performance.NewCollector(cfg.VMWareClient, cfg.Logrus, cfg.Args.PerfMetricFile,
 			cfg.Args.LogAvailableCounters, cfg.Args.PerfLevel, cfg.Args.BatchSizePerfEntities,
-			cfg.Args.BatchSizePerfMetrics)
+			cfg.Args.BatchSizePerfMetrics, cfg.Args.PerfSampleMode, store, counterStore, counterCacheTTL)
 		if err != nil {
 			cfg.Logrus.WithError(err).Fatal("failed to create performance collector")
 		}
 		cfg.PerfCollector = perfCollector
 	}
 
+	cfg.EventStore = newEventStore(cfg)
+
 	runIntegration(cfg)
 
 }
@@ -124,7 +185,7 @@ func checkAndSanitizeConfig(cfg *config.Config) {
 		cfg.Logrus.Fatal("missing argument `pass`, please check if password has been supplied")
 	}
 
-	if cfg.Args.EnableVspherePerfMetrics && cfg.Args.PerfMetricFile == "" {
+	if (cfg.Args.EnableVspherePerfMetrics || cfg.Args.ValidatePerfFile) && cfg.Args.PerfMetricFile == "" {
 		var err error
 		if runtime.GOOS == "windows" {
 			cfg.Args.PerfMetricFile, err = filepath.Abs(config.WindowsPerfMetricFile)
@@ -137,6 +198,114 @@ func checkAndSanitizeConfig(cfg *config.Config) {
 	}
 
 	cfg.Args.DatacenterLocation = strings.ToLower(cfg.Args.DatacenterLocation)
+
+	cfg.SecurityBaseline = model.DefaultSecurityBaseline()
+	if cfg.Args.HostSecurityBaselineFile != "" {
+		baseline, err := model.LoadSecurityBaseline(cfg.Args.HostSecurityBaselineFile)
+		if err != nil {
+			cfg.Logrus.WithError(err).Fatal("failed to load host security baseline")
+		}
+		cfg.SecurityBaseline = baseline
+	}
+
+	thresholds, err := model.ParseExpiryThresholds(cfg.Args.CertificateExpiryThresholds)
+	if err != nil {
+		cfg.Logrus.WithError(err).Fatal("invalid certificate_expiry_thresholds")
+	}
+	cfg.ExpiryThresholds = thresholds
+
+	thresholds, err = model.ParseExpiryThresholds(cfg.Args.LicenseExpiryThresholds)
+	if err != nil {
+		cfg.Logrus.WithError(err).Fatal("invalid license_expiry_thresholds")
+	}
+	cfg.LicenseThresholds = thresholds
+}
+
+// validatePerfFile checks the performance metrics file against the counters available in the vCenter and prints
+// the result to stdout, no data is collected. An error is returned if the file could not be validated or has problems
+func validatePerfFile(cfg *config.Config) error {
+	counterStore, counterCacheTTL := newPerfCounterStore(cfg)
+	perfCollector, err := performance.NewCollector(cfg.VMWareClient, cfg.Logrus, cfg.Args.PerfMetricFile,
+		false, cfg.Args.PerfLevel, cfg.Args.BatchSizePerfEntities,
+		cfg.Args.BatchSizePerfMetrics, performance.SampleModeLatest, nil, counterStore, counterCacheTTL)
+	if err != nil {
+		return fmt.Errorf("failed to create performance collector: %w", err)
+	}
+
+	problems, err := perfCollector.ValidateConfigFile(cfg.Args.PerfMetricFile, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to validate performance metrics file: %w", err)
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problems found in the performance metrics file", problems)
+	}
+	cfg.Logrus.Info("performance metrics file validated, no problems found")
+	return nil
+}
+
+func newPerfCheckpointStore(cfg *config.Config) persist.Storer {
+	// we have to set a distinct default path otherwise it gets overwritten by the default Infra SDK store
+	path := persist.DefaultPath(cfg.IntegrationName + "_perf_checkpoints")
+	store, err := persist.NewFileStore(path, cfg.Logrus, time.Hour)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warn("could not create store for perf checkpoints. only the latest sample will be collected")
+		return persist.NewInMemoryStore()
+	}
+	return store
+}
+
+// newPerfCounterStore returns the store used to cache the perf counter catalogue and its TTL.
+// A nil store is returned if the cache is disabled.
+func newPerfCounterStore(cfg *config.Config) (persist.Storer, time.Duration) {
+	ttl, err := time.ParseDuration(cfg.Args.PerfCountersCacheTTL)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warnf("invalid perf_counters_cache_ttl, using %s", config.DefaultPerfCountersCacheTTL)
+		ttl = config.DefaultPerfCountersCacheTTL
+	}
+	if ttl <= 0 {
+		return nil, 0
+	}
+
+	path := persist.DefaultPath(cfg.IntegrationName + "_perf_counters")
+	store, err := persist.NewFileStore(path, cfg.Logrus, ttl)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warn("could not create store for perf counters. counters will be fetched on every execution")
+		return nil, 0
+	}
+	return store, ttl
+}
+
+// newTagCacheStore returns the store used to cache tags across executions and its TTL.
+// A nil store is returned if the cache is disabled.
+func newTagCacheStore(cfg *config.Config) (persist.Storer, time.Duration) {
+	ttl, err := time.ParseDuration(cfg.Args.TagsCacheTTL)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warn("invalid tags_cache_ttl, tags will be fetched on every execution")
+		return nil, 0
+	}
+	if ttl <= 0 {
+		return nil, 0
+	}
+
+	path := persist.DefaultPath(cfg.IntegrationName + "_tags")
+	store, err := persist.NewFileStore(path, cfg.Logrus, ttl)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warn("could not create store for tags. tags will be fetched on every execution")
+		return nil, 0
+	}
+	return store, ttl
+}
+
+// newEventStore returns the store keeping the thresholds already notified for each certificate and license, so that
+// an event is sent only when a new threshold is reached
+func newEventStore(cfg *config.Config) persist.Storer {
+	path := persist.DefaultPath(cfg.IntegrationName + "_events")
+	store, err := persist.NewFileStore(path, cfg.Logrus, eventStoreTTL)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warn("could not create store for events. certificate and license events will be sent on every execution")
+		return persist.NewInMemoryStore()
+	}
+	return store
 }
 
 func setupLogger(config *config.Config) {
@@ -164,6 +333,22 @@ func runIntegration(config *config.Config) {
 		config.Logrus.WithError(err).Fatal("failed to publish")
 	}
 
+	// thresholds are recorded only once the events have been published, otherwise they would not be sent again
+	if config.EventStore != nil {
+		err = config.EventStore.Save()
+		if err != nil {
+			config.Logrus.WithError(err).Warn("failed to save events store")
+		}
+	}
+
+	// checkpoints are saved only once the samples have been published, otherwise they would be lost
+	if config.PerfMetricsCollectionEnabled() {
+		err = config.PerfCollector.SaveCheckpoints()
+		if err != nil {
+			config.Logrus.WithError(err).Warn("failed to save perf samples checkpoints")
+		}
+	}
+
 }
 
 func infraIntegration(config *config.Config) error {
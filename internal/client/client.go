@@ -5,12 +5,19 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
 	"net/url"
 
+	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vsan"
 )
 
 func LogoutRest(restClient *rest.Client) error {
@@ -53,10 +60,65 @@ func New(vmURL string, vmUsername string, vmPassword string, ValidateSSL bool) (
 	return govmomi.NewClient(ctx, urlParsed, !ValidateSSL)
 }
 
+// NewWithCertificate create new VMWare client as New, returning as well the certificate presented by the endpoint,
+// nil if the connection is not over TLS. When stats is not nil the API calls are counted in it starting from the login
+func NewWithCertificate(vmURL string, vmUsername string, vmPassword string, ValidateSSL bool, stats *model.RunStats) (*govmomi.Client, *x509.Certificate, error) {
+	ctx := context.Background()
+
+	// // Parse URL from string
+	urlParsed, err := soap.ParseURL(vmURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Override username and/or password as required
+	setCredentials(urlParsed, vmUsername, vmPassword)
+
+	// Same as govmomi.NewClient, capturing the certificate during the TLS handshake
+	var peerCertificate *x509.Certificate
+	soapClient := soap.NewClient(urlParsed, !ValidateSSL)
+	soapClient.DefaultTransport().TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) > 0 {
+			peerCertificate = cs.PeerCertificates[0]
+		}
+		return nil
+	}
+
+	// Connect and log in to ESX/i or vCenter
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if stats != nil {
+		// the service content retrieval made by vim25.NewClient
+		stats.AddAPICall(err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if stats != nil {
+		vimClient.RoundTripper = &statsRoundTripper{roundTripper: vimClient.RoundTripper, stats: stats}
+	}
+	c := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: session.NewManager(vimClient),
+	}
+	if urlParsed.User != nil {
+		err = c.Login(ctx, urlParsed.User)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	// the certificate is not updated by the connections of the clients sharing the transport
+	soapClient.DefaultTransport().TLSClientConfig.VerifyConnection = nil
+	return c, peerCertificate, nil
+}
+
 // New create new VMWare rest client
 func NewRest(clientvim25 *govmomi.Client, vmUsername string, vmPassword string) (*rest.Client, error) {
 	ctx := context.Background()
 	re := rest.NewClient(clientvim25.Client)
+	// the calls are counted from the login in the same run stats of the vim25 client, if it counts them
+	if rt, ok := clientvim25.Client.RoundTripper.(*statsRoundTripper); ok {
+		CountRestAPICalls(re, rt.stats)
+	}
 
 	userInfo := url.UserPassword(vmUsername, vmPassword)
 
@@ -67,6 +129,20 @@ func NewRest(clientvim25 *govmomi.Client, vmUsername string, vmPassword string)
 	return re, nil
 }
 
+// NewVsan create new vSAN health client sharing the session of the VMWare client
+func NewVsan(clientvim25 *govmomi.Client) (*vsan.Client, error) {
+	ctx := context.Background()
+	vsanClient, err := vsan.NewClient(ctx, clientvim25.Client)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create vsan client:%v", err)
+	}
+	// the calls are counted in the same run stats of the vim25 client, if it counts them
+	if rt, ok := clientvim25.Client.RoundTripper.(*statsRoundTripper); ok {
+		CountVsanAPICalls(vsanClient, rt.stats)
+	}
+	return vsanClient, nil
+}
+
 func setCredentials(u *url.URL, un string, pw string) {
 	// Override username if provided
 	if un != "" {
@@ -91,3 +167,52 @@ func setCredentials(u *url.URL, un string, pw string) {
 		u.User = url.UserPassword(username, pw)
 	}
 }
+
+// statsRoundTripper counts the calls made through the wrapped RoundTripper and the ones failing
+type statsRoundTripper struct {
+	roundTripper soap.RoundTripper
+	stats        *model.RunStats
+}
+
+func (rt *statsRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	err := rt.roundTripper.RoundTrip(ctx, req, res)
+	rt.stats.AddAPICall(err)
+	return err
+}
+
+// statsTransport counts the http requests made through the wrapped transport and the ones failing or having
+// an error status
+type statsTransport struct {
+	transport http.RoundTripper
+	stats     *model.RunStats
+}
+
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.transport.RoundTrip(req)
+	if err == nil && res.StatusCode >= http.StatusBadRequest {
+		t.stats.AddAPICall(fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, res.Status))
+	} else {
+		t.stats.AddAPICall(err)
+	}
+	return res, err
+}
+
+// CountAPICalls makes the client count its API calls and errors in the run stats
+func CountAPICalls(c *govmomi.Client, stats *model.RunStats) {
+	c.Client.RoundTripper = &statsRoundTripper{roundTripper: c.Client.RoundTripper, stats: stats}
+}
+
+// CountRestAPICalls makes the rest client, used for tags and appliance health, count its API calls and errors
+// in the run stats
+func CountRestAPICalls(c *rest.Client, stats *model.RunStats) {
+	transport := c.Client.Client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c.Client.Client.Transport = &statsTransport{transport: transport, stats: stats}
+}
+
+// CountVsanAPICalls makes the vSAN client count its API calls and errors in the run stats
+func CountVsanAPICalls(c *vsan.Client, stats *model.RunStats) {
+	c.RoundTripper = &statsRoundTripper{roundTripper: c.RoundTripper, stats: stats}
+}
@@ -1,10 +1,17 @@
 package client
 
 import (
+	"context"
 	"net/url"
 	"testing"
 
+	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
 )
 
 func TestSetCredentials(t *testing.T) {
@@ -12,3 +19,48 @@ func TestSetCredentials(t *testing.T) {
 	setCredentials(&u, "user", "password")
 	assert.Equal(t, u.User, url.UserPassword("user", "password"))
 }
+
+func TestNewWithCertificateCapturesCertificate(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c, cert, err := NewWithCertificate(vc.URL().String(), "user", "pass", false, nil)
+		require.NoError(t, err)
+		defer Logout(c)
+
+		require.NotNil(t, cert)
+		assert.False(t, cert.NotAfter.IsZero())
+	})
+}
+
+func TestCountAPICalls(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		stats := model.NewRunStats()
+		c, _, err := NewWithCertificate(vc.URL().String(), "user", "pass", false, stats)
+		require.NoError(t, err)
+		defer Logout(c)
+
+		// the service content retrieval and the login are counted
+		calls, _ := stats.APICalls()
+		assert.Equal(t, int64(2), calls)
+
+		// the rest client counts its calls from the login in the same run stats
+		restClient, err := NewRest(c, "user", "pass")
+		require.NoError(t, err)
+		calls, _ = stats.APICalls()
+		assert.Equal(t, int64(3), calls)
+
+		_, err = c.SessionManager.UserSession(ctx)
+		require.NoError(t, err)
+		calls, _ = stats.APICalls()
+		assert.Equal(t, int64(4), calls)
+
+		// rest calls failing with an error status are counted as errors
+		m := tags.NewManager(restClient)
+		_, err = m.GetCategories(ctx)
+		require.NoError(t, err)
+		_, err = m.GetTag(ctx, "missing")
+		require.Error(t, err)
+		calls, errors := stats.APICalls()
+		assert.Greater(t, calls, int64(5))
+		assert.Equal(t, int64(1), errors)
+	})
+}
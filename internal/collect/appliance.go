@@ -0,0 +1,156 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vapi/rest"
+)
+
+const (
+	applianceMonitoringPath      = "/api/appliance/monitoring"
+	applianceMonitoringQueryPath = "/api/appliance/monitoring/query"
+	applianceServicesPath        = "/api/vcenter/services"
+
+	// monitoring items reporting the used and total size in KB of each appliance partition, es: storage.used.filesystem.seat
+	partitionUsedPrefix  = "storage.used.filesystem."
+	partitionTotalPrefix = "storage.totalsize.filesystem."
+	// the appliance stores monitoring data every 5 minutes
+	applianceMonitoringWindow = 15 * time.Minute
+)
+
+// applianceHealthComponents are the appliance health components and their endpoints
+var applianceHealthComponents = []struct {
+	name string
+	path string
+}{
+	{"system", "/api/appliance/health/system"},
+	{"memory", "/api/appliance/health/mem"},
+	{"storage", "/api/appliance/health/storage"},
+	{"databaseStorage", "/api/appliance/health/database-storage"},
+	{"swap", "/api/appliance/health/swap"},
+	{"load", "/api/appliance/health/load"},
+	{"services", "/api/appliance/health/applmgmt"},
+}
+
+type applianceMonitoredItem struct {
+	ID string `json:"id"`
+}
+
+type applianceMonitoredItemData struct {
+	Name string   `json:"name"`
+	Data []string `json:"data"`
+}
+
+// Appliance collects the health of the vCenter appliance, the usage of its partitions and the state of its services.
+// Failing endpoints are skipped, so partial data is reported.
+func Appliance(config *config.Config) {
+	ctx := context.Background()
+	c := config.RestClient
+	health := model.NewApplianceHealth()
+
+	for _, component := range applianceHealthComponents {
+		var color string
+		err := c.Do(ctx, c.Resource(component.path).Request(http.MethodGet), &color)
+		if err != nil {
+			config.Logrus.WithError(err).WithField("component", component.name).Warn("failed to get appliance health")
+			continue
+		}
+		health.Components[component.name] = color
+	}
+
+	err := appliancePartitions(ctx, c, health)
+	if err != nil {
+		config.Logrus.WithError(err).Warn("failed to get appliance partitions usage")
+	}
+
+	err = c.Do(ctx, c.Resource(applianceServicesPath).Request(http.MethodGet), &health.Services)
+	if err != nil {
+		config.Logrus.WithError(err).Warn("failed to get appliance services")
+	}
+
+	config.ApplianceHealth = health
+}
+
+// appliancePartitions queries the latest used and total size of the partitions monitored by the appliance
+func appliancePartitions(ctx context.Context, c *rest.Client, health *model.ApplianceHealth) error {
+	var items []applianceMonitoredItem
+	err := c.Do(ctx, c.Resource(applianceMonitoringPath).Request(http.MethodGet), &items)
+	if err != nil {
+		return err
+	}
+
+	end := time.Now().UTC()
+	query := c.Resource(applianceMonitoringQueryPath).
+		WithParam("interval", "MINUTES5").
+		WithParam("function", "MAX").
+		WithParam("start_time", end.Add(-applianceMonitoringWindow).Format(time.RFC3339)).
+		WithParam("end_time", end.Format(time.RFC3339))
+	queried := 0
+	for _, item := range items {
+		if strings.HasPrefix(item.ID, partitionUsedPrefix) || strings.HasPrefix(item.ID, partitionTotalPrefix) {
+			query.WithParam("names", item.ID)
+			queried++
+		}
+	}
+	if queried == 0 {
+		return nil
+	}
+
+	var data []applianceMonitoredItemData
+	err = c.Do(ctx, query.Request(http.MethodGet), &data)
+	if err != nil {
+		return err
+	}
+	parsePartitionUsage(data, health)
+	return nil
+}
+
+// parsePartitionUsage sets the usage of each partition from the last value reported by the monitoring items
+func parsePartitionUsage(data []applianceMonitoredItemData, health *model.ApplianceHealth) {
+	for _, item := range data {
+		value, ok := lastMonitoredValue(item.Data)
+		if !ok {
+			continue
+		}
+		var partition string
+		var used bool
+		switch {
+		case strings.HasPrefix(item.Name, partitionUsedPrefix):
+			partition, used = strings.TrimPrefix(item.Name, partitionUsedPrefix), true
+		case strings.HasPrefix(item.Name, partitionTotalPrefix):
+			partition = strings.TrimPrefix(item.Name, partitionTotalPrefix)
+		default:
+			continue
+		}
+
+		usage, ok := health.Partitions[partition]
+		if !ok {
+			usage = &model.PartitionUsage{}
+			health.Partitions[partition] = usage
+		}
+		if used {
+			usage.UsedKB = value
+		} else {
+			usage.TotalKB = value
+		}
+	}
+}
+
+// lastMonitoredValue returns the last value of a monitoring item, intervals without data are empty strings
+func lastMonitoredValue(data []string) (float64, bool) {
+	for i := len(data) - 1; i >= 0; i-- {
+		if value, err := strconv.ParseFloat(data[i], 64); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
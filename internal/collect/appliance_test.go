@@ -0,0 +1,80 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_Appliance(t *testing.T) {
+	m := simulator.VPX()
+	require.NoError(t, m.Create())
+
+	reply := func(path string, body interface{}) {
+		m.Service.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(body)
+		})
+	}
+	for _, component := range applianceHealthComponents {
+		// the database storage endpoint is not available
+		if component.name == "databaseStorage" {
+			continue
+		}
+		reply(component.path, "green")
+	}
+	reply(applianceMonitoringPath, []applianceMonitoredItem{
+		{ID: "storage.used.filesystem.seat"},
+		{ID: "storage.totalsize.filesystem.seat"},
+		{ID: "cpu.util"},
+	})
+	m.Service.HandleFunc(applianceMonitoringQueryPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.ElementsMatch(t, []string{"storage.used.filesystem.seat", "storage.totalsize.filesystem.seat"}, r.URL.Query()["names"])
+		_ = json.NewEncoder(w).Encode([]applianceMonitoredItemData{
+			{Name: "storage.used.filesystem.seat", Data: []string{"1048576", "2097152", ""}},
+			{Name: "storage.totalsize.filesystem.seat", Data: []string{"", "10485760", ""}},
+		})
+	})
+	reply(applianceServicesPath, map[string]model.ApplianceService{
+		"vpxd":        {State: "STARTED", Health: "HEALTHY", StartupType: "AUTOMATIC"},
+		"vsphere-ui":  {State: "STOPPED", StartupType: "AUTOMATIC"},
+		"vmcam":       {State: "STOPPED", StartupType: "MANUAL"},
+		"content-lib": {State: "STARTED", Health: "DEGRADED", StartupType: "AUTOMATIC"},
+	})
+
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+		restClient, err := client.NewRest(vmClient, "user", "pass")
+		require.NoError(t, err)
+
+		cfg := &config.Config{
+			Args:             config.ArgumentList{EnableApplianceHealth: true},
+			IsVcenterAPIType: true,
+			VMWareClient:     vmClient,
+			RestClient:       restClient,
+			Logrus:           logrus.StandardLogger(),
+		}
+
+		Appliance(cfg)
+
+		health := cfg.ApplianceHealth
+		require.NotNil(t, health)
+		assert.Equal(t, "green", health.Components["system"])
+		assert.Equal(t, "green", health.Components["services"])
+		assert.NotContains(t, health.Components, "databaseStorage")
+		assert.Equal(t, &model.PartitionUsage{UsedKB: 2097152, TotalKB: 10485760}, health.Partitions["seat"])
+		assert.Len(t, health.Services, 4)
+		assert.Equal(t, "STOPPED", health.Services["vsphere-ui"].State)
+	}, m)
+}
@@ -18,7 +18,7 @@ func Clusters(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	propertiesToRetrieve := []string{"summary", "host", "datastore", "name", "network", "configuration"}
+	propertiesToRetrieve := withCustomAttributes(config, []string{"summary", "host", "datastore", "name", "network", "configuration", "configurationEx"})
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -54,6 +54,7 @@ func Clusters(config *config.Config) {
 		var clusterRefs []types.ManagedObjectReference
 		for j, cluster := range clusters {
 			config.Datacenters[i].Clusters[cluster.Self] = &clusters[j]
+			cacheCustomAttributes(config, &clusters[j].ManagedEntity)
 
 			// filtering here only affects performance metrics collection
 			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(cluster.Reference()) {
@@ -64,7 +65,8 @@ func Clusters(config *config.Config) {
 
 		if config.PerfMetricsCollectionEnabled() {
 			metricsToCollect := config.PerfCollector.MetricDefinition.ClusterComputeResource
-			collectedData := config.PerfCollector.Collect(clusterRefs, metricsToCollect, performance.FiveMinutesInterval)
+			intervalID := config.PerfCollector.IntervalID(clusterRefs, performance.FiveMinutesInterval)
+			collectedData := config.PerfCollector.Collect(clusterRefs, metricsToCollect, intervalID)
 			dc.AddPerfMetrics(collectedData)
 
 			logger.WithField("seconds", config.Uptime()).Debug("clusters perf metrics collected")
@@ -4,76 +4,151 @@ import (
 	"errors"
 	"github.com/newrelic/nri-vsphere/internal/config"
 	"sync"
+	"time"
 )
 
 const (
-	DATACENTER      = "Datacenter"
-	VIRTUAL_MACHINE = "VirtualMachine"
-	DATASTORE       = "Datastore"
-	HOST            = "HostSystem"
-	RESOURCE_POOL   = "ResourcePool"
-	NETWORK         = "Network"
-	CLUSTER         = "ClusterComputeResource"
+	DATACENTER            = "Datacenter"
+	VIRTUAL_MACHINE       = "VirtualMachine"
+	DATASTORE             = "Datastore"
+	STORAGE_POD           = "StoragePod"
+	HOST                  = "HostSystem"
+	RESOURCE_POOL         = "ResourcePool"
+	VIRTUAL_APP           = "VirtualApp"
+	NETWORK               = "Network"
+	DISTRIBUTED_PORTGROUP = "DistributedVirtualPortgroup"
+	CLUSTER               = "ClusterComputeResource"
 )
 
 func CollectData(config *config.Config) error {
 
 	if config.TagCollectionEnabled() {
+		start := time.Now()
 		err := config.TagCollector.BuildTagCache()
 		if err != nil {
 			config.Logrus.WithError(err).Error("failed to build tag cache")
 		}
+
+		// objects inheriting the filter tags are not attached to them, so they cannot be resolved up front
+		if config.Args.IncludeTags != "" && !config.TagInheritanceEnabled() {
+			err = config.TagCollector.ResolveFilteredObjects()
+			if err != nil {
+				config.Logrus.WithError(err).Warn("failed to resolve the objects matching include_tags, properties of all objects will be retrieved")
+			}
+		}
+		config.RunStats.RecordPhase("tags", time.Since(start))
 	}
 	config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting tags")
 
+	start := time.Now()
 	err := Datacenters(config)
 	if err != nil {
 		return err
 	}
+	config.RunStats.RecordPhase("datacenters", time.Since(start))
 	config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting dc data")
 
 	if len(config.Datacenters) == 0 {
 		return errors.New("no datacenter was collected. this is most likely an error in your filter")
 	}
 
+	// inherited tags are needed before the collectors filter the objects
+	if config.TagInheritanceEnabled() {
+		start := time.Now()
+		err = config.TagCollector.BuildTagInheritance(config.ViewManager, config.VMWareClient.ServiceContent.RootFolder)
+		if err != nil {
+			config.Logrus.WithError(err).Warn("failed to build tag inheritance, only tags attached to the objects will be used")
+		}
+		config.RunStats.RecordPhase("tagInheritance", time.Since(start))
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting inherited tags")
+	}
+
 	// fetch vmware data async
 	var wg sync.WaitGroup
-	wg.Add(6)
+	wg.Add(7)
 	go func() {
 		defer wg.Done()
+		start := time.Now()
 		VirtualMachines(config)
+		config.RunStats.RecordPhase("vms", time.Since(start))
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting vms data")
 	}()
 	go func() {
 		defer wg.Done()
+		start := time.Now()
 		Networks(config)
+		config.RunStats.RecordPhase("networks", time.Since(start))
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting network data")
 
 	}()
 	go func() {
 		defer wg.Done()
+		start := time.Now()
 		Hosts(config)
+		config.RunStats.RecordPhase("hosts", time.Since(start))
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting hosts data")
 	}()
 	go func() {
 		defer wg.Done()
+		start := time.Now()
 		Datastores(config)
+		config.RunStats.RecordPhase("datastores", time.Since(start))
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting datastores data")
 
 	}()
 	go func() {
 		defer wg.Done()
+		start := time.Now()
+		DatastoreClusters(config)
+		config.RunStats.RecordPhase("datastoreClusters", time.Since(start))
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting datastore clusters data")
+	}()
+	go func() {
+		defer wg.Done()
+		start := time.Now()
 		Clusters(config)
+		config.RunStats.RecordPhase("clusters", time.Since(start))
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting clusters data")
 
 	}()
 	go func() {
 		defer wg.Done()
+		start := time.Now()
 		ResourcePools(config)
+		config.RunStats.RecordPhase("resourcePools", time.Since(start))
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting resourcepools data")
 
 	}()
 	wg.Wait()
 
+	// vSAN data is collected only for the clusters already collected
+	if config.VsanCollectionEnabled() {
+		start := time.Now()
+		VsanClusters(config)
+		config.RunStats.RecordPhase("vsan", time.Since(start))
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting vsan data")
+	}
+
+	if config.LicensesEnabled() {
+		start := time.Now()
+		Licenses(config)
+		config.RunStats.RecordPhase("licenses", time.Since(start))
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting licenses data")
+	}
+
+	if config.ApplianceHealthEnabled() {
+		start := time.Now()
+		Appliance(config)
+		config.RunStats.RecordPhase("appliance", time.Since(start))
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting appliance data")
+	}
+
+	if config.TagCollectionEnabled() {
+		err = config.TagCollector.SaveCache()
+		if err != nil {
+			config.Logrus.WithError(err).Warn("failed to save tags cache")
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,25 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// withCustomAttributes adds the custom attributes properties to the properties to retrieve when they are enabled
+func withCustomAttributes(config *config.Config, props []string) []string {
+	if !config.CustomAttributesEnabled() {
+		return props
+	}
+	return append(append([]string{}, props...), model.CustomAttributeProperties...)
+}
+
+// cacheCustomAttributes makes the custom attributes of the entity available to the tag filters
+func cacheCustomAttributes(config *config.Config, entity *mo.ManagedEntity) {
+	if config.CustomAttributesEnabled() && config.TagFilteringEnabled() {
+		config.TagCollector.AddCustomAttributes(entity.Self, model.CustomAttributes(entity))
+	}
+}
@@ -33,7 +33,7 @@ func Datacenters(config *config.Config) error {
 	}()
 
 	var datacenters []mo.Datacenter
-	err = cv.Retrieve(ctx, []string{DATACENTER}, []string{"name", "overallStatus"}, &datacenters)
+	err = cv.Retrieve(ctx, []string{DATACENTER}, withCustomAttributes(config, []string{"name", "overallStatus"}), &datacenters)
 	if err != nil {
 		config.Logrus.WithError(err).Error("failed to retrieve Datacenters")
 		return err
@@ -53,6 +53,8 @@ func Datacenters(config *config.Config) error {
 	}
 
 	for i, d := range datacenters {
+		cacheCustomAttributes(config, &datacenters[i].ManagedEntity)
+
 		// for datacenters we keep the filtering here since there it is the root of the resource tree
 		if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(d.Reference()) {
 			config.Logrus.WithField("datacenter", d.Name).
@@ -0,0 +1,57 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// DatastoreClusters VMWare
+func DatastoreClusters(config *config.Config) {
+	ctx := context.Background()
+	m := config.ViewManager
+
+	// Reference: https://vdc-download.vmware.com/vmwb-repository/dcr-public/b50dcbbf-051d-4204-a3e7-e1b618c1e384/538cf2ec-b34f-4bae-a332-3820ef9e7773/vim.StoragePod.html
+	propertiesToRetrieve := withCustomAttributes(config, []string{"name", "summary", "childEntity", "podStorageDrsEntry", "overallStatus"})
+	for i, dc := range config.Datacenters {
+		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+		cv, err := m.CreateContainerView(ctx, dc.Datacenter.Reference(), []string{STORAGE_POD}, true)
+		if err != nil {
+			logger.WithError(err).Error("failed to create StoragePod container view")
+			continue
+		}
+
+		defer func() {
+			err := cv.Destroy(ctx)
+			if err != nil {
+				logger.WithError(err).Error("error while cleaning up datastore clusters container view")
+			}
+		}()
+
+		var pods []mo.StoragePod
+		err = cv.Retrieve(ctx, []string{STORAGE_POD}, propertiesToRetrieve, &pods)
+		if err != nil {
+			logger.WithError(err).Error("failed to retrieve StoragePods")
+			continue
+		}
+
+		if config.TagCollectionEnabled() {
+			_, err = config.TagCollector.FetchTagsForObjects(pods)
+			if err != nil {
+				logger.WithError(err).Warn("failed to retrieve tags for datastore clusters")
+			} else {
+				logger.WithField("seconds", config.Uptime()).Debug("datastore clusters tags collected")
+			}
+		}
+
+		for j, pod := range pods {
+			config.Datacenters[i].DatastoreClusters[pod.Self] = &pods[j]
+			cacheCustomAttributes(config, &pods[j].ManagedEntity)
+		}
+	}
+}
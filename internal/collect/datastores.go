@@ -18,7 +18,7 @@ func Datastores(config *config.Config) {
 	m := config.ViewManager
 
 	// Reference: https://code.vmware.com/apis/42/vsphere/doc/vim.Datastore.html
-	propertiesToRetrieve := []string{"name", "summary", "overallStatus", "vm", "host", "info"}
+	propertiesToRetrieve := withCustomAttributes(config, []string{"name", "summary", "overallStatus", "vm", "host", "info"})
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -34,8 +34,8 @@ func Datastores(config *config.Config) {
 			}
 		}()
 
-		var datastores []mo.Datastore
-		err = cv.Retrieve(ctx, []string{DATASTORE}, propertiesToRetrieve, &datastores)
+		// name and summary of datastores not matching the tag filter are needed by the vm, cluster and datacenter samples
+		datastores, err := retrieveObjects[mo.Datastore](ctx, config, cv, DATASTORE, propertiesToRetrieve, []string{"name", "summary"})
 		if err != nil {
 			logger.WithError(err).Error("failed to retrieve Datastore")
 			continue
@@ -54,8 +54,9 @@ func Datastores(config *config.Config) {
 		var dsRefs []types.ManagedObjectReference
 		for j, ds := range datastores {
 			config.Datacenters[i].Datastores[ds.Self] = &datastores[j]
+			cacheCustomAttributes(config, &datastores[j].ManagedEntity)
 
-			// filtering here only affects performance metrics collection
+			// objects not matching the filter are skipped by the processors as well
 			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(ds.Reference()) {
 				continue
 			}
@@ -64,7 +65,8 @@ func Datastores(config *config.Config) {
 
 		if config.PerfMetricsCollectionEnabled() {
 			metricsToCollect := config.PerfCollector.MetricDefinition.Datastore
-			collectedData := config.PerfCollector.Collect(dsRefs, metricsToCollect, performance.FiveMinutesInterval)
+			intervalID := config.PerfCollector.IntervalID(dsRefs, performance.FiveMinutesInterval)
+			collectedData := config.PerfCollector.Collect(dsRefs, metricsToCollect, intervalID)
 			dc.AddPerfMetrics(collectedData)
 
 			logger.WithField("seconds", config.Uptime()).Debug("datastores perf metrics collected")
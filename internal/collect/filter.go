@@ -0,0 +1,70 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// retrieveObjects retrieves the objects of the given kind in the container view.
+// When the objects matching the include_tags filter have been resolved up front, all properties are retrieved only
+// for the objects that can match it. The remaining ones are still retrieved with the reduced set of properties
+// needed by the samples of other entities, es: datacenter totals, vm host name, cluster host list.
+func retrieveObjects[T any](ctx context.Context, config *config.Config, cv *view.ContainerView, kind string, props []string, reducedProps []string) ([]T, error) {
+	var objects []T
+
+	candidates, ok := filteredObjects(config)
+	if !ok {
+		err := cv.Retrieve(ctx, []string{kind}, props, &objects)
+		return objects, err
+	}
+
+	refs, err := cv.Find(ctx, []string{kind}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching, others []types.ManagedObjectReference
+	for _, ref := range refs {
+		if candidates[ref] {
+			matching = append(matching, ref)
+		} else {
+			others = append(others, ref)
+		}
+	}
+	config.Logrus.WithField("kind", kind).
+		WithField("matching", len(matching)).
+		WithField("total", len(refs)).
+		Debug("retrieving properties of the objects matching the tag filter")
+
+	pc := property.DefaultCollector(config.VMWareClient.Client)
+	if len(matching) > 0 {
+		err = pc.Retrieve(ctx, matching, props, &objects)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(others) > 0 {
+		var reduced []T
+		err = pc.Retrieve(ctx, others, reducedProps, &reduced)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, reduced...)
+	}
+	return objects, nil
+}
+
+// filteredObjects returns the objects that can match the include_tags filter if they have been resolved
+func filteredObjects(config *config.Config) (map[types.ManagedObjectReference]bool, bool) {
+	if !config.TagFilteringEnabled() || config.TagCollector == nil {
+		return nil, false
+	}
+	return config.TagCollector.FilteredObjects()
+}
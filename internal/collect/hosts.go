@@ -6,6 +6,7 @@ package collect
 import (
 	"context"
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/newrelic/nri-vsphere/internal/performance"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -18,7 +19,11 @@ func Hosts(config *config.Config) {
 	m := config.ViewManager
 
 	// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.HostSystem.html
-	propertiesToRetrieve := []string{"summary", "overallStatus", "config", "network", "vm", "runtime", "parent", "datastore"}
+	propertiesToRetrieve := withCustomAttributes(config, []string{"summary", "overallStatus", "config", "network", "vm", "runtime", "parent", "datastore", "capability.tpmSupported", "capability.tpmVersion"})
+	// bios and system info are reported only in the inventory
+	if config.Args.HasInventory() {
+		propertiesToRetrieve = append(propertiesToRetrieve, "hardware.biosInfo", "hardware.systemInfo")
+	}
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -35,8 +40,13 @@ func Hosts(config *config.Config) {
 			}
 		}()
 
-		var hosts []mo.HostSystem
-		err = cv.Retrieve(ctx, []string{HOST}, propertiesToRetrieve, &hosts)
+		// summary and parent of hosts not matching the tag filter are needed by the vm, cluster and datacenter samples
+		// and their vSAN config by the disk groups of the vSAN cluster sample
+		reducedProperties := []string{"summary", "parent"}
+		if config.VsanCollectionEnabled() {
+			reducedProperties = append(reducedProperties, "config.vsanHostConfig")
+		}
+		hosts, err := retrieveObjects[mo.HostSystem](ctx, config, cv, HOST, propertiesToRetrieve, reducedProperties)
 		if err != nil {
 			logger.WithError(err).Error("failed to retrieve HostSystems")
 			continue
@@ -54,17 +64,24 @@ func Hosts(config *config.Config) {
 		var hostsRefs []types.ManagedObjectReference
 		for j, host := range hosts {
 			config.Datacenters[i].Hosts[host.Self] = &hosts[j]
+			cacheCustomAttributes(config, &hosts[j].ManagedEntity)
 
-			// filtering here only affects performance metrics collection
+			// objects not matching the filter are skipped by the processors as well
 			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(host.Reference()) {
 				continue
 			}
+			// vCenter has no perf data for hosts not connected or in maintenance
+			if reason := model.HostPerfSkippedReason(&hosts[j]); reason != "" {
+				logger.WithField("host", host.Summary.Config.Name).WithField("reason", reason).Trace("skipping perf metrics collection")
+				continue
+			}
 			hostsRefs = append(hostsRefs, host.Self)
 		}
 
 		if config.PerfMetricsCollectionEnabled() {
 			metricsToCollect := config.PerfCollector.MetricDefinition.Host
-			collectedData := config.PerfCollector.Collect(hostsRefs, metricsToCollect, performance.RealTimeInterval)
+			intervalID := config.PerfCollector.IntervalID(hostsRefs, performance.RealTimeInterval)
+			collectedData := config.PerfCollector.Collect(hostsRefs, metricsToCollect, intervalID)
 			dc.AddPerfMetrics(collectedData)
 
 			logger.WithField("seconds", config.Uptime()).Debug("hosts perf metrics collected")
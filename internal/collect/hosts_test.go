@@ -111,3 +111,61 @@ func addHostTag(ctx context.Context, m *tags.Manager, vc *vim25.Client, category
 		_ = m.AttachTag(ctx, tagID, h.Reference())
 	}
 }
+
+func Test_ListHosts_WithResolvedFilterAndVsan_RetrievesVsanConfigForAllHosts(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		assert.NoError(t, err)
+
+		c := rest.NewClient(vc)
+		err = c.Login(ctx, simulator.DefaultLogin)
+		assert.NoError(t, err)
+
+		m := tags.NewManager(c)
+		categoryID, err := m.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "SINGLE"})
+		assert.NoError(t, err)
+		tagID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "prod"})
+		assert.NoError(t, err)
+		tagged, err := find.NewFinder(vc).HostSystem(ctx, "/DC0/host/DC0_H0/DC0_H0")
+		assert.NoError(t, err)
+		assert.NoError(t, m.AttachTag(ctx, tagID, tagged.Reference()))
+
+		// given
+		collector := tag.NewCollector(m, logrus.StandardLogger())
+		assert.NoError(t, collector.BuildTagCache())
+		assert.NoError(t, collector.ParseFilterTagExpression("env=prod"))
+		assert.NoError(t, collector.ResolveFilteredObjects())
+
+		cfg := &config.Config{
+			Args: config.ArgumentList{
+				EnableVsphereTags: true,
+				EnableVsan:        true,
+				IncludeTags:       "env=prod",
+			},
+			IsVcenterAPIType: true,
+			VMWareClient:     vmClient,
+			ViewManager:      view.NewManager(vc),
+			TagCollector:     collector,
+			Logrus:           logrus.StandardLogger(),
+		}
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, cfg.ViewManager))
+
+		// when
+		Hosts(cfg)
+
+		// then
+		hosts := cfg.Datacenters[0].Hosts
+		assert.Len(t, hosts, 4, "all hosts are still listed")
+		for ref, host := range hosts {
+			// disk groups of hosts not matching the filter are counted in the vSAN cluster sample
+			if assert.NotNil(t, host.Config) {
+				assert.NotNil(t, host.Config.VsanHostConfig)
+			}
+			if ref != tagged.Reference() {
+				assert.Nil(t, host.Config.Network)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/license"
+)
+
+// Licenses collects the license keys of the vCenter and the entities each one is assigned to
+func Licenses(config *config.Config) {
+	ctx := context.Background()
+	m := license.NewManager(config.VMWareClient.Client)
+
+	infos, err := m.List(ctx)
+	if err != nil {
+		config.Logrus.WithError(err).Error("failed to retrieve licenses")
+		return
+	}
+
+	assignedEntities, assignmentsRetrieved := licenseAssignments(ctx, config, m)
+
+	licenses := make([]*model.License, 0, len(infos))
+	for _, info := range infos {
+		licenses = append(licenses, &model.License{
+			Info:                 info,
+			AssignedEntities:     assignedEntities[info.LicenseKey],
+			AssignmentsRetrieved: assignmentsRetrieved,
+		})
+	}
+	config.Licenses = licenses
+}
+
+// licenseAssignments returns the names of the entities each license key is assigned to, false if the assignments
+// could not be retrieved
+func licenseAssignments(ctx context.Context, config *config.Config, m *license.Manager) (map[string][]string, bool) {
+	am, err := m.AssignmentManager(ctx)
+	if err != nil {
+		config.Logrus.WithError(err).Warn("failed to get license assignment manager, assigned entities will not be reported")
+		return nil, false
+	}
+	// an empty entity id returns the assignments of every entity
+	assignments, err := am.QueryAssigned(ctx, "")
+	if err != nil {
+		config.Logrus.WithError(err).Warn("failed to retrieve license assignments, assigned entities will not be reported")
+		return nil, false
+	}
+
+	assignedEntities := map[string][]string{}
+	for _, assignment := range assignments {
+		name := assignment.EntityDisplayName
+		if name == "" {
+			name = assignment.EntityId
+		}
+		if name == "" {
+			continue
+		}
+		key := assignment.AssignedLicense.LicenseKey
+		assignedEntities[key] = append(assignedEntities[key], name)
+	}
+	return assignedEntities, true
+}
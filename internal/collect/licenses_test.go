@@ -0,0 +1,36 @@
+package collect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_Licenses(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		cfg := &config.Config{
+			IsVcenterAPIType: true,
+			VMWareClient:     vmClient,
+			Logrus:           logrus.StandardLogger(),
+		}
+
+		Licenses(cfg)
+
+		// the simulator has only the evaluation license, its assignments have no entity
+		require.Len(t, cfg.Licenses, 1)
+		assert.Equal(t, simulator.EvalLicense.LicenseKey, cfg.Licenses[0].Info.LicenseKey)
+		assert.Equal(t, "eval", cfg.Licenses[0].Info.EditionKey)
+		assert.True(t, cfg.Licenses[0].AssignmentsRetrieved)
+		assert.Empty(t, cfg.Licenses[0].AssignedEntities)
+	})
+}
@@ -7,8 +7,10 @@ import (
 	"context"
 
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/performance"
 
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 // Networks ESXi
@@ -17,7 +19,7 @@ func Networks(config *config.Config) {
 	m := config.ViewManager
 
 	// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.Network.html
-	propertiesToRetrieve := []string{"name"}
+	propertiesToRetrieve := withCustomAttributes(config, []string{"name"})
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -39,8 +41,35 @@ func Networks(config *config.Config) {
 			logger.WithError(err).Error("failed to retrieve Networks")
 			continue
 		}
+		// the Network view includes distributed port groups, they are queried separately since they have their own metrics
+		var networkRefs, portgroupRefs []types.ManagedObjectReference
 		for j := 0; j < len(networks); j++ {
 			config.Datacenters[i].Networks[networks[j].Self] = &networks[j]
+
+			if networks[j].Self.Type == DISTRIBUTED_PORTGROUP {
+				portgroupRefs = append(portgroupRefs, networks[j].Self)
+			} else {
+				networkRefs = append(networkRefs, networks[j].Self)
+			}
+		}
+
+		// perf metrics are not available for networks in most vCenters, so they are queried only if configured
+		if config.PerfMetricsCollectionEnabled() {
+			metricsToCollect := config.PerfCollector.MetricDefinition.Network
+			if len(metricsToCollect) > 0 {
+				intervalID := config.PerfCollector.IntervalID(networkRefs, performance.FiveMinutesInterval)
+				collectedData := config.PerfCollector.Collect(networkRefs, metricsToCollect, intervalID)
+				dc.AddPerfMetrics(collectedData)
+			}
+
+			metricsToCollect = config.PerfCollector.MetricDefinition.DistributedVirtualPortgroup
+			if len(metricsToCollect) > 0 {
+				intervalID := config.PerfCollector.IntervalID(portgroupRefs, performance.FiveMinutesInterval)
+				collectedData := config.PerfCollector.Collect(portgroupRefs, metricsToCollect, intervalID)
+				dc.AddPerfMetrics(collectedData)
+			}
+
+			logger.WithField("seconds", config.Uptime()).Debug("networks perf metrics collected")
 		}
 	}
 }
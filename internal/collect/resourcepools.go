@@ -17,7 +17,7 @@ func ResourcePools(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	propertiesToRetrieve := []string{"summary", "owner", "parent", "runtime", "name", "overallStatus", "vm", "resourcePool"}
+	propertiesToRetrieve := withCustomAttributes(config, []string{"summary", "config", "owner", "parent", "runtime", "name", "overallStatus", "vm", "resourcePool"})
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -50,20 +50,36 @@ func ResourcePools(config *config.Config) {
 			}
 		}
 
-		var rpRefs []types.ManagedObjectReference
+		// the ResourcePool view includes virtual apps, they are queried separately since they have their own metrics
+		var rpRefs, vAppRefs []types.ManagedObjectReference
 		for j, rp := range resourcePools {
 			config.Datacenters[i].ResourcePools[rp.Self] = &resourcePools[j]
+			cacheCustomAttributes(config, &resourcePools[j].ManagedEntity)
 
 			// filtering here only affects performance metrics collection
 			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(rp.Reference()) {
 				continue
 			}
-			rpRefs = append(rpRefs, rp.Self)
+			if rp.Self.Type == VIRTUAL_APP {
+				vAppRefs = append(vAppRefs, rp.Self)
+			} else {
+				rpRefs = append(rpRefs, rp.Self)
+			}
 		}
 
 		if config.PerfMetricsCollectionEnabled() {
 			metricsToCollect := config.PerfCollector.MetricDefinition.ResourcePool
-			collectedData := config.PerfCollector.Collect(rpRefs, metricsToCollect, performance.FiveMinutesInterval)
+			intervalID := config.PerfCollector.IntervalID(rpRefs, performance.FiveMinutesInterval)
+			collectedData := config.PerfCollector.Collect(rpRefs, metricsToCollect, intervalID)
+			dc.AddPerfMetrics(collectedData)
+
+			// metrics files written before the virtualApp section existed keep using the resource pool counters
+			metricsToCollect = config.PerfCollector.MetricDefinition.VirtualApp
+			if len(metricsToCollect) == 0 {
+				metricsToCollect = config.PerfCollector.MetricDefinition.ResourcePool
+			}
+			intervalID = config.PerfCollector.IntervalID(vAppRefs, performance.FiveMinutesInterval)
+			collectedData = config.PerfCollector.Collect(vAppRefs, metricsToCollect, intervalID)
 			dc.AddPerfMetrics(collectedData)
 
 			logger.WithField("seconds", config.Uptime()).Debug("resource pools perf metrics collected")
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/newrelic/nri-vsphere/internal/performance"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -23,6 +24,7 @@ func VirtualMachines(config *config.Config) {
 		config.Logrus.Debug("collecting as well snapshot and layoutEx properties")
 		propertiesToRetrieve = append(propertiesToRetrieve, "snapshot", "layoutEx.file", "layoutEx.disk", "layoutEx.snapshot")
 	}
+	propertiesToRetrieve = withCustomAttributes(config, propertiesToRetrieve)
 
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
@@ -42,8 +44,8 @@ func VirtualMachines(config *config.Config) {
 
 		logger.WithField("seconds", config.Uptime().Seconds()).Debug("before collecting vm data method.Retrieve")
 
-		var vms []mo.VirtualMachine
-		err = cv.Retrieve(ctx, []string{VIRTUAL_MACHINE}, propertiesToRetrieve, &vms)
+		// vms not matching the tag filter are only counted in the datacenter sample
+		vms, err := retrieveObjects[mo.VirtualMachine](ctx, config, cv, VIRTUAL_MACHINE, propertiesToRetrieve, []string{"name"})
 		if err != nil {
 			logger.WithError(err).WithField("datacenter", dc.Datacenter.Name).
 				Error("failed to retrieve VM data for datacenter")
@@ -63,17 +65,24 @@ func VirtualMachines(config *config.Config) {
 		var vmRefs []types.ManagedObjectReference
 		for j, vm := range vms {
 			config.Datacenters[i].VirtualMachines[vm.Self] = &vms[j]
+			cacheCustomAttributes(config, &vms[j].ManagedEntity)
 
-			// filtering here only affects performance metrics collection
+			// objects not matching the filter are skipped by the processors as well
 			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(vms[j].Reference()) {
 				continue
 			}
+			// vCenter has no perf data for templates and vms not running
+			if reason := model.VMPerfSkippedReason(&vms[j]); reason != "" {
+				logger.WithField("vm", vm.Name).WithField("reason", reason).Trace("skipping perf metrics collection")
+				continue
+			}
 			vmRefs = append(vmRefs, vm.Self)
 		}
 
 		if config.PerfMetricsCollectionEnabled() {
 			metricsToCollect := config.PerfCollector.MetricDefinition.VM
-			collectedData := config.PerfCollector.Collect(vmRefs, metricsToCollect, performance.RealTimeInterval)
+			intervalID := config.PerfCollector.IntervalID(vmRefs, performance.RealTimeInterval)
+			collectedData := config.PerfCollector.Collect(vmRefs, metricsToCollect, intervalID)
 			dc.AddPerfMetrics(collectedData)
 
 			logger.WithField("seconds", config.Uptime()).Debug("vms perf metrics collected")
@@ -131,3 +131,61 @@ func addVmTag(ctx context.Context, m *tags.Manager, vc *vim25.Client, category s
 		_ = m.AttachTag(ctx, tagID, vm.Reference())
 	}
 }
+
+func Test_ListVirtualMachines_WithResolvedFilter_RetrievesPropertiesOnlyForMatchingVirtualMachines(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		assert.NoError(t, err)
+
+		c := rest.NewClient(vc)
+		err = c.Login(ctx, simulator.DefaultLogin)
+		assert.NoError(t, err)
+
+		m := tags.NewManager(c)
+		categoryID, err := m.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "SINGLE"})
+		assert.NoError(t, err)
+		tagID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "prod"})
+		assert.NoError(t, err)
+		tagged, err := find.NewFinder(vc).VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+		assert.NoError(t, err)
+		assert.NoError(t, m.AttachTag(ctx, tagID, tagged.Reference()))
+
+		// given
+		collector := tag.NewCollector(m, logrus.StandardLogger())
+		assert.NoError(t, collector.BuildTagCache())
+		assert.NoError(t, collector.ParseFilterTagExpression("env=prod"))
+		assert.NoError(t, collector.ResolveFilteredObjects())
+
+		cfg := &config.Config{
+			Args: config.ArgumentList{
+				EnableVsphereTags: true,
+				IncludeTags:       "env=prod",
+			},
+			IsVcenterAPIType: true,
+			VMWareClient:     vmClient,
+			ViewManager:      view.NewManager(vc),
+			TagCollector:     collector,
+			Logrus:           logrus.StandardLogger(),
+		}
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, cfg.ViewManager))
+
+		// when
+		VirtualMachines(cfg)
+
+		// then
+		vms := cfg.Datacenters[0].VirtualMachines
+		assert.Len(t, vms, 4, "all vms are still listed")
+		for ref, vm := range vms {
+			if ref == tagged.Reference() {
+				assert.NotNil(t, vm.Config)
+				assert.True(t, collector.MatchObjectTags(ref))
+			} else {
+				assert.Nil(t, vm.Config)
+				assert.NotEmpty(t, vm.Name)
+				assert.False(t, collector.MatchObjectTags(ref))
+			}
+		}
+
+		return nil
+	})
+}
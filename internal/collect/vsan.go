@@ -0,0 +1,153 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vsan"
+	"github.com/vmware/govmomi/vsan/methods"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+// vSAN health service managed objects not exposed by the govmomi vsan package
+var (
+	vsanSpaceReportSystemInstance = types.ManagedObjectReference{
+		Type:  "VsanSpaceReportSystem",
+		Value: "vsan-cluster-space-report-system",
+	}
+	vsanClusterHealthSystemInstance = types.ManagedObjectReference{
+		Type:  "VsanVcClusterHealthSystem",
+		Value: "vsan-cluster-health-system",
+	}
+)
+
+const (
+	// vsanPerfEntity is the vSAN performance entity aggregating the I/O of the cluster virtual machines
+	vsanPerfEntity = "cluster-domclient:*"
+	// vsanPerfWindow covers at least one sample of the vSAN performance service, collected every 5 minutes
+	vsanPerfWindow = 15 * time.Minute
+)
+
+// VsanClusters collects capacity, resync, health and performance data of the clusters with vSAN enabled.
+// It must run after the clusters have been collected.
+func VsanClusters(config *config.Config) {
+	ctx := context.Background()
+
+	for _, dc := range config.Datacenters {
+		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+		for _, cluster := range dc.Clusters {
+			// filtering here will to avoid calling the vSAN health service for clusters not reported
+			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(cluster.Self) {
+				continue
+			}
+
+			clusterLogger := logger.WithField("cluster", cluster.Name)
+			cfg, err := config.VsanClient.VsanClusterGetConfig(ctx, cluster.Self)
+			if err != nil {
+				clusterLogger.WithError(err).Warn("failed to retrieve vSAN config")
+				continue
+			}
+			if !model.VsanEnabled(cfg) {
+				continue
+			}
+
+			dc.VsanClusters[cluster.Self] = &model.VsanCluster{
+				Config:      cfg,
+				SpaceUsage:  vsanSpaceUsage(ctx, config.VsanClient, cluster.Self, clusterLogger),
+				Resync:      vsanResync(ctx, config.VsanClient, cluster.Self, clusterLogger),
+				Health:      vsanHealth(ctx, config.VsanClient, cluster.Self, clusterLogger),
+				PerfMetrics: vsanPerfMetrics(ctx, config.VsanClient, cluster.Self, cfg, clusterLogger),
+			}
+		}
+		logger.WithField("seconds", config.Uptime()).Debug("vsan clusters collected")
+	}
+}
+
+func vsanSpaceUsage(ctx context.Context, c *vsan.Client, cluster types.ManagedObjectReference, logger *logrus.Entry) *vsantypes.VsanSpaceUsage {
+	res, err := methods.VsanQuerySpaceUsage(ctx, c, &vsantypes.VsanQuerySpaceUsage{
+		This:    vsanSpaceReportSystemInstance,
+		Cluster: cluster,
+	})
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve vSAN space usage")
+		return nil
+	}
+	return &res.Returnval
+}
+
+func vsanResync(ctx context.Context, c *vsan.Client, cluster types.ManagedObjectReference, logger *logrus.Entry) *vsantypes.VsanHostVsanObjectSyncQueryResult {
+	res, err := methods.QuerySyncingVsanObjectsSummary(ctx, c, &vsantypes.QuerySyncingVsanObjectsSummary{
+		This:    vsan.VsanQueryObjectIdentitiesInstance,
+		Cluster: cluster,
+	})
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve vSAN resync summary")
+		return nil
+	}
+	return &res.Returnval
+}
+
+func vsanHealth(ctx context.Context, c *vsan.Client, cluster types.ManagedObjectReference, logger *logrus.Entry) *vsantypes.VsanClusterHealthSummary {
+	// health checks are expensive, the result of the last run of the vSAN health service is returned instead
+	fetchFromCache := true
+	res, err := methods.VsanQueryVcClusterHealthSummary(ctx, c, &vsantypes.VsanQueryVcClusterHealthSummary{
+		This:           vsanClusterHealthSystemInstance,
+		Cluster:        &cluster,
+		Fields:         []string{"overallHealth", "overallHealthDescription", "groups"},
+		FetchFromCache: &fetchFromCache,
+	})
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve vSAN health summary")
+		return nil
+	}
+	return &res.Returnval
+}
+
+// vsanPerfMetrics returns the most recent value of each cluster metric of the vSAN performance service,
+// nil if the service is disabled
+func vsanPerfMetrics(ctx context.Context, c *vsan.Client, cluster types.ManagedObjectReference, cfg *vsantypes.VsanConfigInfoEx, logger *logrus.Entry) map[string]float64 {
+	if cfg.PerfsvcConfig == nil || !cfg.PerfsvcConfig.Enabled {
+		return nil
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-vsanPerfWindow)
+	res, err := c.VsanPerfQueryPerf(ctx, &cluster, []vsantypes.VsanPerfQuerySpec{{
+		EntityRefId: vsanPerfEntity,
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+	}})
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve vSAN performance metrics")
+		return nil
+	}
+	return parseVsanPerfMetrics(res)
+}
+
+// parseVsanPerfMetrics returns the last value of each series, values are returned as comma separated strings
+func parseVsanPerfMetrics(entities []vsantypes.VsanPerfEntityMetricCSV) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, entity := range entities {
+		for _, series := range entity.Value {
+			values := strings.Split(series.Values, ",")
+			for i := len(values) - 1; i >= 0; i-- {
+				value, err := strconv.ParseFloat(values[i], 64)
+				if err == nil {
+					metrics[series.MetricId.Label] = value
+					break
+				}
+			}
+		}
+	}
+	return metrics
+}
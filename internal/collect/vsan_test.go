@@ -0,0 +1,57 @@
+package collect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	_ "github.com/vmware/govmomi/vsan/simulator"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+func Test_VsanClusters_SkipsClustersWithVsanDisabled(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+		vsanClient, err := client.NewVsan(vmClient)
+		require.NoError(t, err)
+
+		cfg := &config.Config{
+			Args:             config.ArgumentList{EnableVsan: true},
+			IsVcenterAPIType: true,
+			VMWareClient:     vmClient,
+			VsanClient:       vsanClient,
+			ViewManager:      view.NewManager(vc),
+			Logrus:           logrus.StandardLogger(),
+		}
+		err = Datacenters(cfg)
+		require.NoError(t, err)
+		Clusters(cfg)
+		require.NotEmpty(t, cfg.Datacenters[0].Clusters)
+
+		VsanClusters(cfg)
+
+		assert.Empty(t, cfg.Datacenters[0].VsanClusters)
+		return nil
+	})
+}
+
+func Test_parseVsanPerfMetrics(t *testing.T) {
+	metrics := parseVsanPerfMetrics([]vsantypes.VsanPerfEntityMetricCSV{{
+		EntityRefId: "cluster-domclient:52a3",
+		Value: []vsantypes.VsanPerfMetricSeriesCSV{
+			{MetricId: vsantypes.VsanPerfMetricId{Label: "iopsRead"}, Values: "10,20,30"},
+			{MetricId: vsantypes.VsanPerfMetricId{Label: "latencyAvgRead"}, Values: "1500,"},
+			{MetricId: vsantypes.VsanPerfMetricId{Label: "congestion"}, Values: ""},
+		},
+	}})
+
+	assert.Equal(t, map[string]float64{"iopsRead": 30, "latencyAvgRead": 1500}, metrics)
+}
@@ -4,6 +4,7 @@
 package config
 
 import (
+	"crypto/x509"
 	"flag"
 	"fmt"
 	"os"
@@ -15,9 +16,12 @@ import (
 
 	sdkArgs "github.com/newrelic/infra-integrations-sdk/v3/args"
 	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 	logrus "github.com/sirupsen/logrus"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vsan"
 )
 
 // ArgumentList Available Arguments
@@ -37,6 +41,9 @@ type ArgumentList struct {
 	PerfLevel                int    `default:"1" help:"Performance counter level of performance metrics that will be collected"`
 	LogAvailableCounters     bool   `default:"false" help:"Print available performance metrics"`
 	PerfMetricFile           string `default:"" help:"Location of performance metrics configuration file"`
+	PerfCountersCacheTTL     string `default:"24h" help:"How long the performance counters catalogue fetched from the vCenter is cached, 0 disables the cache"`
+	ValidatePerfFile         bool   `default:"false" help:"Validate the performance metrics file against the counters available in the vCenter, print the available counters and exit"`
+	PerfSampleMode           string `default:"latest" help:"Performance samples reported: 'latest' only the most recent one, 'all' every sample since the previous execution with its own timestamp, 'summary' min, max, avg and p95 of the samples since the previous execution"`
 
 	//As a general rule, specify between 10 and 50 entities in a single call to the QueryPerf method.
 	//This is a general recommendation because your system configuration may impose different
@@ -46,11 +53,25 @@ type ArgumentList struct {
 	BatchSizePerfMetrics  string `default:"50" help:"Number of metrics requested at the same time when querying performance metrics"`
 
 	EnableVsphereTags      bool `default:"false" help:"Set to collect tags. Tags are available when connecting to vcenter"`
+	EnableTagInheritance   bool `default:"false" help:"Set to let VMs inherit tags from their resource pools, host, cluster, folders and datacenter, and hosts from their cluster and datacenter"`
+	EnableCustomAttributes bool `default:"false" help:"Set to collect custom attributes, reported as customAttribute.<name> and usable in the tag filters. Custom attributes are available when connecting to vcenter"`
 	EnableVsphereSnapshots bool `default:"false" help:"Set to collect and process VMs Snapshots data"`
+	EnableVsan             bool `default:"false" help:"Set to collect capacity, resync, health and performance data of the clusters with vSAN enabled. vSAN data is available when connecting to vcenter"`
+	EnableApplianceHealth  bool `default:"false" help:"Set to collect the health components, partitions usage and services state of the vCenter appliance. Appliance data is available when connecting to vcenter"`
+	EnableLicenses         bool `default:"false" help:"Set to collect the capacity usage, expiration and assigned entities of the vCenter licenses. Licenses are available when connecting to vcenter"`
 	ValidateSSL            bool `default:"false" help:"Set to validates SSL when connecting to vCenter or Esxi Host"`
 	ShowVersion            bool `default:"false" help:"Print build information and exit"`
 
-	IncludeTags string `default:"" help:"Space-separated list of tag categories and values for resource inclusion. \nIf defined, only resources tagged with any of the tags will be included in the results. \nYou must also include 'enable_vsphere_tags' in order for this option to work. \nExample: --include_tags env=prod dc=eu"`
+	IncludeTags string `default:"" help:"Tag filter expression for resource inclusion. \nIf defined, only resources whose tags match the expression will be included in the results. \nIt supports category=value terms, where value can contain * and ? wildcards, combined with AND, OR, NOT and parentheses, terms without an operator are combined with OR. \nYou must also include 'enable_vsphere_tags' in order for this option to work. \nExample: --include_tags \"env=prod AND NOT backup=excluded\""`
+	ExcludeTags string `default:"" help:"Tag filter expression for resource exclusion, with the same syntax of include_tags. \nIf defined, resources whose tags match the expression will be excluded from the results. \nYou must also include 'enable_vsphere_tags' in order for this option to work. \nExample: --exclude_tags \"backup=* OR env=test\""`
+
+	TagsCacheTTL string `default:"0" help:"How long tag definitions and the tags attached to each object are cached across executions, eg. 1h. Attachments are fetched only for new objects and the ones cached for longer, 0 disables the cache"`
+
+	HostSecurityBaselineFile string `default:"" help:"Location of the yaml file with the hardening baseline hosts are checked against in VSphereHostSecuritySample. If not set a default baseline is used"`
+
+	CertificateExpiryThresholds string `default:"30,7" help:"Comma separated list of days before the expiration of the host and vCenter certificates. An event is sent each time the days left reach one of them"`
+	LicenseExpiryThresholds     string `default:"30,7" help:"Comma separated list of days before the expiration of the vCenter licenses. An event is sent each time the days left reach one of them"`
+	LicenseUsageThreshold       int    `default:"90" help:"Percentage of the capacity units of a license in use above which an event is sent"`
 }
 
 type Config struct {
@@ -65,8 +86,18 @@ type Config struct {
 	VMWareClient         *govmomi.Client          // VMWareClient Client
 	ViewManager          *view.Manager            // ViewManager Client
 	TagCollector         *tag.Collector           // TagsManager Client
+	VsanClient           *vsan.Client             // VsanClient vSAN health Client
 	Datacenters          []*model.Datacenter      // Datacenters VMWare
 	IsVcenterAPIType     bool                     // IsVcenterAPIType true if connecting to vcenter
+	SecurityBaseline     *model.SecurityBaseline  // SecurityBaseline hardening baseline hosts are checked against
+	VcenterCertificate   *x509.Certificate        // VcenterCertificate certificate presented by the vCenter
+	ExpiryThresholds     []int                    // ExpiryThresholds days before certificates expiration an event is sent
+	EventStore           persist.Storer           // EventStore thresholds already notified for each certificate and license
+	RunStats             *model.RunStats          // RunStats duration of the collection phases and API calls of the execution
+	RestClient           *rest.Client             // RestClient vCenter REST API Client
+	ApplianceHealth      *model.ApplianceHealth   // ApplianceHealth health of the vCenter appliance
+	Licenses             []*model.License         // Licenses license keys of the vCenter
+	LicenseThresholds    []int                    // LicenseThresholds days before licenses expiration an event is sent
 	PerfCollector        *performance.PerfCollector
 	startTime            time.Time // start time the integration started.
 }
@@ -89,6 +120,8 @@ func New(buildVersion string) *Config {
 const (
 	WindowsPerfMetricFile      = "C:\\Program Files\\New Relic\\newrelic-infra\\integrations.d\\vsphere-performance.metrics"
 	LinuxDefaultPerfMetricFile = "/etc/newrelic-infra/integrations.d/vsphere-performance.metrics"
+
+	DefaultPerfCountersCacheTTL = 24 * time.Hour
 )
 
 func (c *Config) TagCollectionEnabled() bool {
@@ -99,8 +132,28 @@ func (c *Config) EventCollectionEnabled() bool {
 	return c.IsVcenterAPIType && c.Args.EnableVsphereEvents
 }
 
+func (c *Config) TagInheritanceEnabled() bool {
+	return c.TagCollectionEnabled() && c.Args.EnableTagInheritance
+}
+
+func (c *Config) CustomAttributesEnabled() bool {
+	return c.IsVcenterAPIType && c.Args.EnableCustomAttributes
+}
+
+func (c *Config) VsanCollectionEnabled() bool {
+	return c.IsVcenterAPIType && c.Args.EnableVsan
+}
+
+func (c *Config) ApplianceHealthEnabled() bool {
+	return c.IsVcenterAPIType && c.Args.EnableApplianceHealth
+}
+
+func (c *Config) LicensesEnabled() bool {
+	return c.IsVcenterAPIType && c.Args.EnableLicenses
+}
+
 func (c *Config) TagFilteringEnabled() bool {
-	return c.TagCollectionEnabled() && len(c.Args.IncludeTags) > 0
+	return c.TagCollectionEnabled() && (len(c.Args.IncludeTags) > 0 || len(c.Args.ExcludeTags) > 0)
 }
 
 func (c *Config) PerfMetricsCollectionEnabled() bool {
@@ -0,0 +1,36 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+// ApplianceHealth is the health of the vCenter appliance, fetched from the appliance REST API
+type ApplianceHealth struct {
+	// Components is the color of each health component, es: system: green
+	Components map[string]string
+	// Partitions is the storage usage of each appliance partition, es: seat
+	Partitions map[string]*PartitionUsage
+	// Services are the vmon services by name
+	Services map[string]ApplianceService
+}
+
+// PartitionUsage is the storage usage of an appliance partition in KB
+type PartitionUsage struct {
+	UsedKB  float64
+	TotalKB float64
+}
+
+// ApplianceService is the state of a vmon service
+type ApplianceService struct {
+	State       string `json:"state"`
+	Health      string `json:"health"`
+	StartupType string `json:"startup_type"`
+}
+
+// NewApplianceHealth returns an empty appliance health
+func NewApplianceHealth() *ApplianceHealth {
+	return &ApplianceHealth{
+		Components: make(map[string]string),
+		Partitions: make(map[string]*PartitionUsage),
+		Services:   make(map[string]ApplianceService),
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseCertificate parses the first PEM encoded certificate, as returned in the host config.certificate
+func ParseCertificate(pemBytes []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// CertificateFingerprint returns the hex encoded sha256 fingerprint of the certificate
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// DaysUntilExpiry returns the number of whole days left before the certificate expires, negative once expired
+func DaysUntilExpiry(cert *x509.Certificate, now time.Time) int {
+	return DaysUntil(cert.NotAfter, now)
+}
+
+// DaysUntil returns the number of whole days left before the given time, negative once passed
+func DaysUntil(t time.Time, now time.Time) int {
+	left := t.Sub(now)
+	days := int(left.Hours() / 24)
+	if left < 0 {
+		days--
+	}
+	return days
+}
+
+// ParseExpiryThresholds parses a comma separated list of days, es: "30,7,1", returned in ascending order
+func ParseExpiryThresholds(thresholds string) ([]int, error) {
+	var days []int
+	for _, t := range strings.Split(thresholds, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		d, err := strconv.Atoi(t)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid certificate expiry threshold %q", t)
+		}
+		days = append(days, d)
+	}
+	sort.Ints(days)
+	return days, nil
+}
+
+// CrossedExpiryThreshold returns the lowest threshold reached by the days left, thresholds must be sorted in
+// ascending order. False is returned if the days left are above every threshold.
+func CrossedExpiryThreshold(thresholds []int, daysUntilExpiry int) (int, bool) {
+	for _, t := range thresholds {
+		if daysUntilExpiry <= t {
+			return t, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseCertificate_Invalid(t *testing.T) {
+	_, err := ParseCertificate([]byte("10"))
+	assert.Error(t, err)
+}
+
+func Test_DaysUntilExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cert := &x509.Certificate{NotAfter: now.Add(30*24*time.Hour + time.Hour)}
+	assert.Equal(t, 30, DaysUntilExpiry(cert, now))
+
+	cert = &x509.Certificate{NotAfter: now.Add(-time.Hour)}
+	assert.Equal(t, -1, DaysUntilExpiry(cert, now))
+}
+
+func Test_ExpiryThresholds(t *testing.T) {
+	thresholds, err := ParseExpiryThresholds("7, 30,1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 7, 30}, thresholds)
+
+	_, err = ParseExpiryThresholds("30,a")
+	assert.Error(t, err)
+
+	threshold, crossed := CrossedExpiryThreshold(thresholds, 45)
+	assert.False(t, crossed)
+
+	threshold, crossed = CrossedExpiryThreshold(thresholds, 5)
+	assert.True(t, crossed)
+	assert.Equal(t, 7, threshold)
+
+	threshold, crossed = CrossedExpiryThreshold(thresholds, -3)
+	assert.True(t, crossed)
+	assert.Equal(t, 1, threshold)
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// CustomAttributeProperties are the properties to retrieve to get the custom attributes of an entity
+var CustomAttributeProperties = []string{"customValue", "availableField"}
+
+// CustomAttributes returns the custom attributes set on the entity by name. The name of each attribute is taken from
+// the custom field definitions available for the entity, values of unknown fields are ignored.
+func CustomAttributes(entity *mo.ManagedEntity) map[string]string {
+	if len(entity.CustomValue) == 0 {
+		return nil
+	}
+
+	namesByKey := make(map[int32]string, len(entity.AvailableField))
+	for _, field := range entity.AvailableField {
+		namesByKey[field.Key] = field.Name
+	}
+
+	attributes := make(map[string]string)
+	for _, v := range entity.CustomValue {
+		value, ok := v.(*types.CustomFieldStringValue)
+		if !ok || value.Value == "" {
+			continue
+		}
+		if name, ok := namesByKey[value.Key]; ok {
+			attributes[name] = value.Value
+		}
+	}
+	return attributes
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCustomAttributes(t *testing.T) {
+	entity := &mo.ManagedEntity{
+		ExtensibleManagedObject: mo.ExtensibleManagedObject{
+			AvailableField: []types.CustomFieldDef{
+				{Key: 1, Name: "owner"},
+				{Key: 2, Name: "costCenter"},
+				{Key: 3, Name: "backup"},
+			},
+		},
+		CustomValue: []types.BaseCustomFieldValue{
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 1}, Value: "team-a"},
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 2}, Value: "cc-42"},
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 3}, Value: ""},
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 4}, Value: "unknown"},
+		},
+	}
+
+	assert.Equal(t, map[string]string{"owner": "team-a", "costCenter": "cc-42"}, CustomAttributes(entity))
+	assert.Empty(t, CustomAttributes(&mo.ManagedEntity{}))
+}
@@ -16,29 +16,33 @@ type mor = types.ManagedObjectReference
 
 // Datacenter struct
 type Datacenter struct {
-	Datacenter      *mo.Datacenter
-	EventDispacher  *events.EventDispacher
-	Hosts           map[mor]*mo.HostSystem
-	Clusters        map[mor]*mo.ClusterComputeResource
-	ResourcePools   map[mor]*mo.ResourcePool
-	Datastores      map[mor]*mo.Datastore
-	Networks        map[mor]*mo.Network
-	VirtualMachines map[mor]*mo.VirtualMachine
-	PerfMetrics     map[mor][]performance.PerfMetric
-	PerfMetricsMux  sync.Mutex
+	Datacenter        *mo.Datacenter
+	EventDispacher    *events.EventDispacher
+	Hosts             map[mor]*mo.HostSystem
+	Clusters          map[mor]*mo.ClusterComputeResource
+	ResourcePools     map[mor]*mo.ResourcePool
+	Datastores        map[mor]*mo.Datastore
+	DatastoreClusters map[mor]*mo.StoragePod
+	VsanClusters      map[mor]*VsanCluster
+	Networks          map[mor]*mo.Network
+	VirtualMachines   map[mor]*mo.VirtualMachine
+	PerfMetrics       map[mor][]performance.PerfMetric
+	PerfMetricsMux    sync.Mutex
 }
 
 // NewDatacenter Initialize datacenter struct
 func NewDatacenter(datacenter *mo.Datacenter) *Datacenter {
 	return &Datacenter{
-		Datacenter:      datacenter,
-		Hosts:           make(map[mor]*mo.HostSystem),
-		Clusters:        make(map[mor]*mo.ClusterComputeResource),
-		ResourcePools:   make(map[mor]*mo.ResourcePool),
-		Datastores:      make(map[mor]*mo.Datastore),
-		Networks:        make(map[mor]*mo.Network),
-		VirtualMachines: make(map[mor]*mo.VirtualMachine),
-		PerfMetrics:     make(map[mor][]performance.PerfMetric),
+		Datacenter:        datacenter,
+		Hosts:             make(map[mor]*mo.HostSystem),
+		Clusters:          make(map[mor]*mo.ClusterComputeResource),
+		ResourcePools:     make(map[mor]*mo.ResourcePool),
+		Datastores:        make(map[mor]*mo.Datastore),
+		DatastoreClusters: make(map[mor]*mo.StoragePod),
+		VsanClusters:      make(map[mor]*VsanCluster),
+		Networks:          make(map[mor]*mo.Network),
+		VirtualMachines:   make(map[mor]*mo.VirtualMachine),
+		PerfMetrics:       make(map[mor][]performance.PerfMetric),
 	}
 }
 
@@ -65,6 +69,18 @@ func (dc *Datacenter) FindHost(computeResourceReference mor) *mo.HostSystem {
 	return nil
 }
 
+// FindDatastoreCluster returns the datastore cluster the datastore belongs to
+func (dc *Datacenter) FindDatastoreCluster(datastoreReference mor) (*mo.StoragePod, bool) {
+	for _, pod := range dc.DatastoreClusters {
+		for _, child := range pod.ChildEntity {
+			if child == datastoreReference {
+				return pod, true
+			}
+		}
+	}
+	return nil, false
+}
+
 // GetResourcePool returns the name of the Resource Pool if is not the default
 func (dc *Datacenter) GetResourcePool(resourcePoolReference mor) (*mo.ResourcePool, bool) {
 	if !dc.IsDefaultResourcePool(resourcePoolReference) {
@@ -84,6 +100,26 @@ func (dc *Datacenter) IsDefaultResourcePool(resourcePoolReference mor) bool {
 	return false
 }
 
+// ResourcePoolPath returns the names of the resource pools from the top level one, child of the default resource pool,
+// to the given one, es: [Prod Web]
+func (dc *Datacenter) ResourcePoolPath(resourcePoolReference mor) []string {
+	var path []string
+	seen := map[mor]bool{}
+	for ref := resourcePoolReference; !seen[ref]; {
+		seen[ref] = true
+		rp, ok := dc.GetResourcePool(ref)
+		if !ok {
+			break
+		}
+		path = append([]string{rp.Name}, path...)
+		if rp.Parent == nil {
+			break
+		}
+		ref = *rp.Parent
+	}
+	return path
+}
+
 // AddTags appends a tag batch to dc Tags map
 func (dc *Datacenter) AddPerfMetrics(data map[types.ManagedObjectReference][]performance.PerfMetric) {
 	dc.PerfMetricsMux.Lock()
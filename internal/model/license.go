@@ -0,0 +1,53 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// licensePropertyExpirationDate is the license property holding when the license expires, missing for perpetual licenses
+const licensePropertyExpirationDate = "expirationDate"
+
+// License is a license key of the vCenter with the entities it is assigned to
+type License struct {
+	Info             types.LicenseManagerLicenseInfo
+	AssignedEntities []string
+	// AssignmentsRetrieved is false when the license assignments could not be queried, so AssignedEntities is unknown
+	AssignmentsRetrieved bool
+}
+
+// MaskedKey returns the license key with every character but the last five masked, es: XXXXX-XXXXX-XXXXX-XXXXX-4T2H1
+func (l *License) MaskedKey() string {
+	key := []rune(l.Info.LicenseKey)
+	for i := 0; i < len(key)-5; i++ {
+		if key[i] != '-' {
+			key[i] = 'X'
+		}
+	}
+	return string(key)
+}
+
+// Expiration returns when the license expires, false for licenses not expiring
+func (l *License) Expiration() (time.Time, bool) {
+	for _, property := range l.Info.Properties {
+		if property.Key != licensePropertyExpirationDate {
+			continue
+		}
+		if expiration, ok := property.Value.(time.Time); ok {
+			return expiration, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// UsagePercentage returns the percentage of the capacity units in use, false for licenses with unlimited capacity
+func (l *License) UsagePercentage() (float64, bool) {
+	if l.Info.Total <= 0 {
+		return 0, false
+	}
+	return float64(l.Info.Used) / float64(l.Info.Total) * 100, true
+}
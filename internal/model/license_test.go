@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_License(t *testing.T) {
+	expiration := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	license := &License{Info: types.LicenseManagerLicenseInfo{
+		LicenseKey: "AB123-CD456-EF789-GH012-4T2H1",
+		Total:      16,
+		Used:       12,
+		Properties: []types.KeyAnyValue{
+			{Key: "feature", Value: types.KeyValue{Key: "dvs", Value: "vSphere Distributed Switch"}},
+			{Key: "expirationDate", Value: expiration},
+		},
+	}}
+
+	assert.Equal(t, "XXXXX-XXXXX-XXXXX-XXXXX-4T2H1", license.MaskedKey())
+	usage, ok := license.UsagePercentage()
+	assert.True(t, ok)
+	assert.Equal(t, float64(75), usage)
+	exp, ok := license.Expiration()
+	assert.True(t, ok)
+	assert.Equal(t, expiration, exp)
+
+	// perpetual license with unlimited capacity
+	license = &License{Info: types.LicenseManagerLicenseInfo{LicenseKey: "00000-00000-00000-00000-00000"}}
+	_, ok = license.UsagePercentage()
+	assert.False(t, ok)
+	_, ok = license.Expiration()
+	assert.False(t, ok)
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Reasons why performance metrics are not collected for an entity
+const (
+	PerfSkippedTemplate        = "template"
+	PerfSkippedMaintenanceMode = "maintenanceMode"
+)
+
+// VMPerfSkippedReason returns why performance metrics cannot be collected for the vm, based on its runtime state.
+// It returns an empty string if they can be collected.
+func VMPerfSkippedReason(vm *mo.VirtualMachine) string {
+	if vm.Config != nil && vm.Config.Template {
+		return PerfSkippedTemplate
+	}
+	if vm.Runtime.ConnectionState != "" && vm.Runtime.ConnectionState != types.VirtualMachineConnectionStateConnected {
+		return string(vm.Runtime.ConnectionState)
+	}
+	if vm.Runtime.PowerState != "" && vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		return string(vm.Runtime.PowerState)
+	}
+	return ""
+}
+
+// HostPerfSkippedReason returns why performance metrics cannot be collected for the host, based on its runtime state.
+// It returns an empty string if they can be collected.
+func HostPerfSkippedReason(host *mo.HostSystem) string {
+	if host.Runtime.ConnectionState != "" && host.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+		return string(host.Runtime.ConnectionState)
+	}
+	if host.Runtime.InMaintenanceMode {
+		return PerfSkippedMaintenanceMode
+	}
+	if host.Runtime.PowerState != "" && host.Runtime.PowerState != types.HostSystemPowerStatePoweredOn {
+		return string(host.Runtime.PowerState)
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVMPerfSkippedReason(t *testing.T) {
+	tests := []struct {
+		name string
+		vm   mo.VirtualMachine
+		want string
+	}{
+		{
+			name: "PoweredOn",
+			vm:   mo.VirtualMachine{Runtime: types.VirtualMachineRuntimeInfo{ConnectionState: "connected", PowerState: "poweredOn"}},
+			want: "",
+		},
+		{
+			name: "PoweredOff",
+			vm:   mo.VirtualMachine{Runtime: types.VirtualMachineRuntimeInfo{ConnectionState: "connected", PowerState: "poweredOff"}},
+			want: "poweredOff",
+		},
+		{
+			name: "Template",
+			vm: mo.VirtualMachine{
+				Config:  &types.VirtualMachineConfigInfo{Template: true},
+				Runtime: types.VirtualMachineRuntimeInfo{ConnectionState: "connected", PowerState: "poweredOff"},
+			},
+			want: PerfSkippedTemplate,
+		},
+		{
+			name: "Orphaned",
+			vm:   mo.VirtualMachine{Runtime: types.VirtualMachineRuntimeInfo{ConnectionState: "orphaned", PowerState: "poweredOn"}},
+			want: "orphaned",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, VMPerfSkippedReason(&tt.vm))
+		})
+	}
+}
+
+func TestHostPerfSkippedReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		runtime types.HostRuntimeInfo
+		want    string
+	}{
+		{
+			name:    "Connected",
+			runtime: types.HostRuntimeInfo{ConnectionState: "connected", PowerState: "poweredOn"},
+			want:    "",
+		},
+		{
+			name:    "Disconnected",
+			runtime: types.HostRuntimeInfo{ConnectionState: "disconnected", PowerState: "unknown"},
+			want:    "disconnected",
+		},
+		{
+			name:    "MaintenanceMode",
+			runtime: types.HostRuntimeInfo{ConnectionState: "connected", PowerState: "poweredOn", InMaintenanceMode: true},
+			want:    PerfSkippedMaintenanceMode,
+		},
+		{
+			name:    "StandBy",
+			runtime: types.HostRuntimeInfo{ConnectionState: "connected", PowerState: "standBy"},
+			want:    "standBy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HostPerfSkippedReason(&mo.HostSystem{Runtime: tt.runtime}))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"sort"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// VMPlacement holds the per VM settings found in the configuration of the cluster the VM runs in
+type VMPlacement struct {
+	DrsOverride *types.ClusterDrsVmConfigInfo
+	DasOverride *types.ClusterDasVmConfigInfo
+	// VmGroups are the names of the VM groups the VM belongs to
+	VmGroups []string
+	// VmHostRules are the names of the VM-host rules applying to any of the VM groups
+	VmHostRules []string
+	// VmVmRules are the names of the VM-VM affinity and anti-affinity rules including the VM
+	VmVmRules []string
+}
+
+// ClusterVMPlacements returns the placement settings of the VMs having any override, group or rule in the cluster
+// configuration, indexed by VM reference
+func ClusterVMPlacements(cluster *mo.ClusterComputeResource) map[mor]*VMPlacement {
+	placements := map[mor]*VMPlacement{}
+	cfg, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok || cfg == nil {
+		return placements
+	}
+	get := func(vm mor) *VMPlacement {
+		p, ok := placements[vm]
+		if !ok {
+			p = &VMPlacement{}
+			placements[vm] = p
+		}
+		return p
+	}
+
+	for i := range cfg.DrsVmConfig {
+		get(cfg.DrsVmConfig[i].Key).DrsOverride = &cfg.DrsVmConfig[i]
+	}
+	for i := range cfg.DasVmConfig {
+		get(cfg.DasVmConfig[i].Key).DasOverride = &cfg.DasVmConfig[i]
+	}
+
+	vmsByGroup := map[string][]mor{}
+	for _, g := range cfg.Group {
+		if vmGroup, ok := g.(*types.ClusterVmGroup); ok {
+			vmsByGroup[vmGroup.Name] = vmGroup.Vm
+			for _, vm := range vmGroup.Vm {
+				p := get(vm)
+				p.VmGroups = append(p.VmGroups, vmGroup.Name)
+			}
+		}
+	}
+
+	for _, r := range cfg.Rule {
+		switch rule := r.(type) {
+		case *types.ClusterVmHostRuleInfo:
+			for _, vm := range vmsByGroup[rule.VmGroupName] {
+				p := get(vm)
+				p.VmHostRules = append(p.VmHostRules, rule.Name)
+			}
+		case *types.ClusterAffinityRuleSpec:
+			for _, vm := range rule.Vm {
+				p := get(vm)
+				p.VmVmRules = append(p.VmVmRules, rule.Name)
+			}
+		case *types.ClusterAntiAffinityRuleSpec:
+			for _, vm := range rule.Vm {
+				p := get(vm)
+				p.VmVmRules = append(p.VmVmRules, rule.Name)
+			}
+		}
+	}
+
+	for _, p := range placements {
+		sort.Strings(p.VmGroups)
+		sort.Strings(p.VmHostRules)
+		sort.Strings(p.VmVmRules)
+	}
+	return placements
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RunStats keeps the duration of each collection phase and the number of vSphere API calls of the execution.
+// A nil RunStats can be used and records nothing.
+type RunStats struct {
+	phasesMux sync.Mutex
+	phases    map[string]time.Duration
+	apiCalls  atomic.Int64
+	apiErrors atomic.Int64
+}
+
+// NewRunStats returns empty run stats
+func NewRunStats() *RunStats {
+	return &RunStats{
+		phases: make(map[string]time.Duration),
+	}
+}
+
+// RecordPhase records the duration of a collection phase, es: hosts, vms
+func (s *RunStats) RecordPhase(phase string, duration time.Duration) {
+	if s == nil {
+		return
+	}
+	s.phasesMux.Lock()
+	defer s.phasesMux.Unlock()
+	s.phases[phase] = duration
+}
+
+// Phases returns the duration of each collection phase recorded
+func (s *RunStats) Phases() map[string]time.Duration {
+	phases := make(map[string]time.Duration)
+	if s == nil {
+		return phases
+	}
+	s.phasesMux.Lock()
+	defer s.phasesMux.Unlock()
+	for phase, duration := range s.phases {
+		phases[phase] = duration
+	}
+	return phases
+}
+
+// AddAPICall counts an API call, and an API error if it failed
+func (s *RunStats) AddAPICall(err error) {
+	if s == nil {
+		return
+	}
+	s.apiCalls.Add(1)
+	if err != nil {
+		s.apiErrors.Add(1)
+	}
+}
+
+// APICalls returns the number of API calls and how many of them failed
+func (s *RunStats) APICalls() (calls int64, errors int64) {
+	if s == nil {
+		return 0, 0
+	}
+	return s.apiCalls.Load(), s.apiErrors.Load()
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RunStats(t *testing.T) {
+	stats := NewRunStats()
+	stats.RecordPhase("hosts", time.Second)
+	stats.AddAPICall(nil)
+	stats.AddAPICall(errors.New("fault"))
+
+	assert.Equal(t, map[string]time.Duration{"hosts": time.Second}, stats.Phases())
+	calls, errs := stats.APICalls()
+	assert.Equal(t, int64(2), calls)
+	assert.Equal(t, int64(1), errs)
+
+	// nil stats record nothing
+	var noStats *RunStats
+	noStats.RecordPhase("hosts", time.Second)
+	noStats.AddAPICall(nil)
+	assert.Empty(t, noStats.Phases())
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"gopkg.in/yaml.v2"
+)
+
+// Keys of the host services and advanced options relevant for the host security
+const (
+	serviceKeySSH       = "TSM-SSH"
+	serviceKeyESXiShell = "TSM"
+	serviceKeyNTP       = "ntpd"
+	optionKeySyslogHost = "Syslog.global.logHost"
+)
+
+// HostSecurity is the security posture of a host, built from its config, summary and capability
+type HostSecurity struct {
+	LockdownMode            string
+	SSHRunning              bool
+	SSHPolicy               string
+	ESXiShellRunning        bool
+	ESXiShellPolicy         string
+	NTPServers              []string
+	NTPRunning              bool
+	NTPSynced               *bool
+	FirewallIncomingBlocked *bool
+	FirewallOutgoingBlocked *bool
+	SyslogHost              string
+	TPMSupported            *bool
+	TPMVersion              string
+	TPMAttestationStatus    string
+	SecureBootRequired      *bool
+}
+
+// NewHostSecurity returns the security posture of the host
+func NewHostSecurity(host *mo.HostSystem) HostSecurity {
+	var hs HostSecurity
+
+	if host.Config != nil {
+		hs.LockdownMode = string(host.Config.LockdownMode)
+
+		if host.Config.Service != nil {
+			for _, service := range host.Config.Service.Service {
+				switch service.Key {
+				case serviceKeySSH:
+					hs.SSHRunning = service.Running
+					hs.SSHPolicy = service.Policy
+				case serviceKeyESXiShell:
+					hs.ESXiShellRunning = service.Running
+					hs.ESXiShellPolicy = service.Policy
+				case serviceKeyNTP:
+					hs.NTPRunning = service.Running
+				}
+			}
+		}
+
+		if dt := host.Config.DateTimeInfo; dt != nil {
+			if dt.NtpConfig != nil {
+				hs.NTPServers = dt.NtpConfig.Server
+			}
+			hs.NTPSynced = dt.ServiceSync
+		}
+
+		if host.Config.Firewall != nil {
+			hs.FirewallIncomingBlocked = host.Config.Firewall.DefaultPolicy.IncomingBlocked
+			hs.FirewallOutgoingBlocked = host.Config.Firewall.DefaultPolicy.OutgoingBlocked
+		}
+
+		for _, option := range host.Config.Option {
+			ov := option.GetOptionValue()
+			if ov.Key == optionKeySyslogHost {
+				if value, ok := ov.Value.(string); ok {
+					hs.SyslogHost = value
+				}
+			}
+		}
+	}
+
+	if host.Capability != nil {
+		hs.TPMSupported = host.Capability.TpmSupported
+		hs.TPMVersion = host.Capability.TpmVersion
+	}
+	if host.Summary.TpmAttestation != nil {
+		hs.TPMAttestationStatus = string(host.Summary.TpmAttestation.Status)
+	}
+	// the API does not expose whether the host booted with UEFI Secure Boot, only whether the encryption of the host
+	// state requires it, which is reported since vSphere 7.0 Update 3
+	if host.Runtime.StateEncryption != nil {
+		hs.SecureBootRequired = host.Runtime.StateEncryption.RequireSecureBoot
+	}
+	return hs
+}
+
+// SecurityBaseline is the hardening baseline hosts are checked against. Checks not set are not evaluated.
+type SecurityBaseline struct {
+	LockdownModes           []string `yaml:"lockdownModes"`
+	SSHRunning              *bool    `yaml:"sshRunning"`
+	SSHPolicy               *string  `yaml:"sshPolicy"`
+	ESXiShellRunning        *bool    `yaml:"esxiShellRunning"`
+	ESXiShellPolicy         *string  `yaml:"esxiShellPolicy"`
+	NTPConfigured           *bool    `yaml:"ntpConfigured"`
+	NTPRunning              *bool    `yaml:"ntpRunning"`
+	FirewallIncomingBlocked *bool    `yaml:"firewallIncomingBlocked"`
+	FirewallOutgoingBlocked *bool    `yaml:"firewallOutgoingBlocked"`
+	SyslogConfigured        *bool    `yaml:"syslogConfigured"`
+	TPMAttestationAccepted  *bool    `yaml:"tpmAttestationAccepted"`
+	SecureBootRequired      *bool    `yaml:"secureBootRequired"`
+}
+
+// DefaultSecurityBaseline returns the baseline used when no baseline file is configured
+func DefaultSecurityBaseline() *SecurityBaseline {
+	enabled, disabled := true, false
+	return &SecurityBaseline{
+		LockdownModes:           []string{string(types.HostLockdownModeLockdownNormal), string(types.HostLockdownModeLockdownStrict)},
+		SSHRunning:              &disabled,
+		ESXiShellRunning:        &disabled,
+		NTPConfigured:           &enabled,
+		NTPRunning:              &enabled,
+		FirewallIncomingBlocked: &enabled,
+		SyslogConfigured:        &enabled,
+	}
+}
+
+// LoadSecurityBaseline reads the hardening baseline from a yaml file
+func LoadSecurityBaseline(fileName string) (*SecurityBaseline, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error loading security baseline file: %w", err)
+	}
+	defer file.Close()
+
+	var baseline SecurityBaseline
+	// unknown keys are rejected, otherwise a misspelled check would be silently disabled
+	decoder := yaml.NewDecoder(file)
+	decoder.SetStrict(true)
+	err = decoder.Decode(&baseline)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing security baseline file: %w", err)
+	}
+	return &baseline, nil
+}
+
+// Evaluate returns the number of checks evaluated and the names of the ones failed by the host
+func (b *SecurityBaseline) Evaluate(hs HostSecurity) (int, []string) {
+	checks := 0
+	var failed []string
+	check := func(name string, passed bool) {
+		checks++
+		if !passed {
+			failed = append(failed, name)
+		}
+	}
+
+	if len(b.LockdownModes) > 0 {
+		check("lockdownMode", slices.Contains(b.LockdownModes, hs.LockdownMode))
+	}
+	if b.SSHRunning != nil {
+		check("sshRunning", hs.SSHRunning == *b.SSHRunning)
+	}
+	if b.SSHPolicy != nil {
+		check("sshPolicy", hs.SSHPolicy == *b.SSHPolicy)
+	}
+	if b.ESXiShellRunning != nil {
+		check("esxiShellRunning", hs.ESXiShellRunning == *b.ESXiShellRunning)
+	}
+	if b.ESXiShellPolicy != nil {
+		check("esxiShellPolicy", hs.ESXiShellPolicy == *b.ESXiShellPolicy)
+	}
+	if b.NTPConfigured != nil {
+		check("ntpConfigured", (len(hs.NTPServers) > 0) == *b.NTPConfigured)
+	}
+	if b.NTPRunning != nil {
+		check("ntpRunning", hs.NTPRunning == *b.NTPRunning)
+	}
+	if b.FirewallIncomingBlocked != nil {
+		check("firewallIncomingBlocked", hs.FirewallIncomingBlocked != nil && *hs.FirewallIncomingBlocked == *b.FirewallIncomingBlocked)
+	}
+	if b.FirewallOutgoingBlocked != nil {
+		check("firewallOutgoingBlocked", hs.FirewallOutgoingBlocked != nil && *hs.FirewallOutgoingBlocked == *b.FirewallOutgoingBlocked)
+	}
+	if b.SyslogConfigured != nil {
+		check("syslogConfigured", (hs.SyslogHost != "") == *b.SyslogConfigured)
+	}
+	if b.TPMAttestationAccepted != nil {
+		accepted := hs.TPMAttestationStatus == string(types.HostTpmAttestationInfoAcceptanceStatusAccepted)
+		check("tpmAttestationAccepted", accepted == *b.TPMAttestationAccepted)
+	}
+	if b.SecureBootRequired != nil {
+		check("secureBootRequired", hs.SecureBootRequired != nil && *hs.SecureBootRequired == *b.SecureBootRequired)
+	}
+	return checks, failed
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_SecurityBaseline_Evaluate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "baseline.yml")
+	err := os.WriteFile(file, []byte(`
+lockdownModes:
+  - lockdownNormal
+  - lockdownStrict
+sshRunning: false
+esxiShellPolicy: "off"
+syslogConfigured: true
+`), 0600)
+	require.NoError(t, err)
+
+	baseline, err := LoadSecurityBaseline(file)
+	require.NoError(t, err)
+
+	checks, failed := baseline.Evaluate(HostSecurity{
+		LockdownMode:    "lockdownNormal",
+		SSHRunning:      true,
+		ESXiShellPolicy: "off",
+	})
+
+	assert.Equal(t, 4, checks)
+	assert.Equal(t, []string{"sshRunning", "syslogConfigured"}, failed)
+}
+
+func Test_SecurityBaseline_SecureBootRequired(t *testing.T) {
+	required := true
+	baseline := &SecurityBaseline{SecureBootRequired: &required}
+
+	host := &mo.HostSystem{}
+	host.Runtime.StateEncryption = &types.HostRuntimeInfoStateEncryptionInfo{RequireSecureBoot: &required}
+	hs := NewHostSecurity(host)
+	require.NotNil(t, hs.SecureBootRequired)
+	assert.True(t, *hs.SecureBootRequired)
+	_, failed := baseline.Evaluate(hs)
+	assert.Empty(t, failed)
+
+	// hosts older than vSphere 7.0 Update 3 do not report it and fail the check
+	_, failed = baseline.Evaluate(NewHostSecurity(&mo.HostSystem{}))
+	assert.Equal(t, []string{"secureBootRequired"}, failed)
+}
+
+func Test_LoadSecurityBaseline_MissingFile(t *testing.T) {
+	_, err := LoadSecurityBaseline(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.Error(t, err)
+}
+
+func Test_LoadSecurityBaseline_UnknownKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "baseline.yml")
+	require.NoError(t, os.WriteFile(file, []byte("sshrunning: false\n"), 0600))
+
+	_, err := LoadSecurityBaseline(file)
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package model
+
+import (
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+// VsanCluster holds the data fetched from the vSAN health service for a cluster with vSAN enabled.
+// Every field except Config can be nil if the corresponding call failed or is not supported by the vCenter.
+type VsanCluster struct {
+	Config      *vsantypes.VsanConfigInfoEx
+	SpaceUsage  *vsantypes.VsanSpaceUsage
+	Resync      *vsantypes.VsanHostVsanObjectSyncQueryResult
+	Health      *vsantypes.VsanClusterHealthSummary
+	PerfMetrics map[string]float64
+}
+
+// VsanEnabled returns true if the vSAN service is enabled in the cluster config
+func VsanEnabled(cfg *vsantypes.VsanConfigInfoEx) bool {
+	return cfg != nil && cfg.Enabled != nil && *cfg.Enabled
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package performance
+
+import (
+	"context"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// counterCache keeps the perf counter catalogue between executions, since downloading it from the vCenter
+// on every execution is expensive. The catalogue is specific to each vCenter instance and version.
+type counterCache struct {
+	store persist.Storer
+	ttl   time.Duration
+	key   string
+}
+
+// cachedCounter holds the fields of types.PerfCounterInfo used by the integration. PerfCounterInfo cannot be
+// stored as it is since its descriptions are interfaces that cannot be unmarshalled.
+type cachedCounter struct {
+	Key     int32
+	Group   string
+	Name    string
+	Rollup  string
+	Stats   string
+	Level   int32
+	Unit    string
+	Summary string
+}
+
+// newCounterCache returns a cache for the counters of the vCenter described by about.
+// If store is nil the catalogue is never cached.
+func newCounterCache(store persist.Storer, ttl time.Duration, about types.AboutInfo) *counterCache {
+	if store == nil {
+		return nil
+	}
+	return &counterCache{
+		store: store,
+		ttl:   ttl,
+		key:   "perf_counters_" + about.InstanceUuid + "_" + about.Version,
+	}
+}
+
+// load returns the cached counters, the second value is false if they are missing or expired
+func (cc *counterCache) load() ([]types.PerfCounterInfo, bool) {
+	if cc == nil {
+		return nil, false
+	}
+
+	var cached []cachedCounter
+	ts, err := cc.store.Get(cc.key, &cached)
+	if err != nil || len(cached) == 0 || time.Since(time.Unix(ts, 0)) > cc.ttl {
+		return nil, false
+	}
+
+	counters := make([]types.PerfCounterInfo, 0, len(cached))
+	for _, cached := range cached {
+		counters = append(counters, types.PerfCounterInfo{
+			Key:        cached.Key,
+			GroupInfo:  &types.ElementDescription{Key: cached.Group},
+			NameInfo:   &types.ElementDescription{Key: cached.Name, Description: types.Description{Summary: cached.Summary}},
+			UnitInfo:   &types.ElementDescription{Key: cached.Unit},
+			RollupType: types.PerfSummaryType(cached.Rollup),
+			StatsType:  types.PerfStatsType(cached.Stats),
+			Level:      cached.Level,
+		})
+	}
+	return counters, true
+}
+
+// save stores the counters, the entry is refreshed when the ttl expires
+func (cc *counterCache) save(counters []types.PerfCounterInfo) error {
+	if cc == nil {
+		return nil
+	}
+
+	cached := make([]cachedCounter, 0, len(counters))
+	for _, counter := range counters {
+		cached = append(cached, cachedCounter{
+			Key:     counter.Key,
+			Group:   counter.GroupInfo.GetElementDescription().Key,
+			Name:    counter.NameInfo.GetElementDescription().Key,
+			Rollup:  string(counter.RollupType),
+			Stats:   string(counter.StatsType),
+			Level:   counter.Level,
+			Unit:    counter.UnitInfo.GetElementDescription().Key,
+			Summary: counter.NameInfo.GetElementDescription().Summary,
+		})
+	}
+	cc.store.Set(cc.key, cached)
+	return cc.store.Save()
+}
+
+// counterName returns the name of the counter. If the counter is unknown and the catalogue has been loaded from the
+// cache, the catalogue could be outdated, therefore it is downloaded again from the vCenter.
+func (c *PerfCollector) counterName(counterID int32) (string, bool) {
+	c.countersMutex.RLock()
+	name, ok := c.metricsAvaliableByID[counterID]
+	fromCache := c.countersFromCache
+	c.countersMutex.RUnlock()
+
+	if ok || !fromCache {
+		return name, ok
+	}
+
+	c.refreshCounterMetadata()
+
+	c.countersMutex.RLock()
+	defer c.countersMutex.RUnlock()
+	name, ok = c.metricsAvaliableByID[counterID]
+	return name, ok
+}
+
+// refreshCounterMetadata downloads the counter catalogue from the vCenter replacing the cached one.
+// It happens at most once per execution.
+func (c *PerfCollector) refreshCounterMetadata() {
+	c.countersMutex.Lock()
+	defer c.countersMutex.Unlock()
+
+	if !c.countersFromCache {
+		return
+	}
+	c.countersFromCache = false
+
+	c.logger.Debug("perf counter missing from the cached catalogue, fetching counters from perfManager")
+	counters, err := c.perfManager.CounterInfo(context.Background())
+	if err != nil {
+		c.logger.WithError(err).Warn("failed to refresh available metrics from perfManager")
+		return
+	}
+	if err := c.counterCache.save(counters); err != nil {
+		c.logger.WithError(err).Warn("failed to cache perf counters")
+	}
+	c.setCounters(counters, false)
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package performance
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// retrieveHistoricalIntervals fetches the historical intervals configured in the vCenter
+func (c *PerfCollector) retrieveHistoricalIntervals() error {
+	intervals, err := c.perfManager.HistoricalInterval(context.Background())
+	if err != nil {
+		return err
+	}
+	c.historicalIntervals = intervals
+	return nil
+}
+
+// IntervalID returns the interval that should be used to query the performance metrics of the entities having the
+// same type as refs. The provider summary of the first entity tells if real-time stats are available, in that case
+// its refresh rate is used, otherwise the shortest enabled historical interval configured in the vCenter.
+// If the interval cannot be determined the fallback is returned.
+// The interval is computed once per entity type and reused in the following calls, unless the provider summary query
+// failed, in which case the fallback is not cached and the query is retried in the next call.
+func (c *PerfCollector) IntervalID(refs []types.ManagedObjectReference, fallback int32) int32 {
+	if len(refs) == 0 {
+		return fallback
+	}
+	entityType := refs[0].Type
+
+	c.intervalsMutex.Lock()
+	defer c.intervalsMutex.Unlock()
+
+	if c.intervalsByType == nil {
+		c.intervalsByType = map[string]int32{}
+	}
+	if interval, ok := c.intervalsByType[entityType]; ok {
+		return interval
+	}
+
+	summary, err := c.perfManager.ProviderSummary(context.Background(), refs[0])
+	if err != nil {
+		c.logger.WithError(err).WithField("entityType", entityType).Warn("failed to query perf provider summary, using default interval")
+		return fallback
+	}
+	interval := c.selectInterval(entityType, summary, fallback)
+	c.logger.WithField("entityType", entityType).WithField("interval", interval).Debug("perf metrics interval")
+
+	c.intervalsByType[entityType] = interval
+	return interval
+}
+
+func (c *PerfCollector) selectInterval(entityType string, summary *types.PerfProviderSummary, fallback int32) int32 {
+	if summary.CurrentSupported && summary.RefreshRate > 0 {
+		return summary.RefreshRate
+	}
+
+	if !summary.SummarySupported {
+		c.logger.WithField("entityType", entityType).Warn("no perf stats are available for the entity type, using default interval")
+		return fallback
+	}
+
+	var shortest *types.PerfInterval
+	for i, hi := range c.historicalIntervals {
+		if !hi.Enabled {
+			continue
+		}
+		if shortest == nil || hi.SamplingPeriod < shortest.SamplingPeriod {
+			shortest = &c.historicalIntervals[i]
+		}
+	}
+	if shortest == nil {
+		c.logger.WithField("entityType", entityType).Warn("no historical interval is enabled in the vCenter, using default interval")
+		return fallback
+	}
+
+	// counters having a level higher than the one of the interval are not stored by the vCenter
+	if int32(c.collectionLevel) > shortest.Level {
+		c.logger.WithField("entityType", entityType).
+			WithField("interval", shortest.Name).
+			WithField("intervalLevel", shortest.Level).
+			WithField("perfLevel", c.collectionLevel).
+			Warnf("perf level is higher than the statistics level of the interval, counters above level %d will have no data", shortest.Level)
+	}
+	return shortest.SamplingPeriod
+}
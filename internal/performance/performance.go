@@ -8,10 +8,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 	logrus "github.com/sirupsen/logrus"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/performance"
@@ -20,10 +25,20 @@ import (
 )
 
 const (
-	counterLimit = 150 // limits the number of perf metrics to be added to avoid reach the 256 limit per event
-
 	RealTimeInterval    = 20
 	FiveMinutesInterval = 300
+
+	// SampleModeLatest reports only the most recent sample of each counter
+	SampleModeLatest = "latest"
+	// SampleModeAll reports every sample retrieved since the previous execution, each one with its own timestamp
+	SampleModeAll = "all"
+	// SampleModeSummary reports min, max, avg and p95 of the samples retrieved since the previous execution
+	SampleModeSummary = "summary"
+
+	// Perf counter levels defined by VMware, in the metrics file they are referred as level_1 to level_4
+	levelPrefix = "level_"
+	minLevel    = 1
+	maxLevel    = 4
 )
 
 type PerfCollector struct {
@@ -35,17 +50,29 @@ type PerfCollector struct {
 
 	metricsAvaliableByID   map[int32]string
 	metricsAvaliableByName map[string]int32
+	countersByID           map[int32]types.PerfCounterInfo
+	countersMutex          sync.RWMutex
+	counterCache           *counterCache
+	countersFromCache      bool
 	batchSizePerfEntities  int
 	batchSizePerfMetrics   int
+
+	sampleMode  string
+	checkpoints *checkpoints
+
+	historicalIntervals []types.PerfInterval
+	intervalsByType     map[string]int32
+	intervalsMutex      sync.Mutex
 }
 
 //this struct is not needed we can decide to pass more info and process it in the process, it would hide logic
 type PerfMetric struct {
-	Value   int64
-	Counter string
+	Value     int64
+	Counter   string
+	Timestamp time.Time // Timestamp of the sample as reported by vCenter
 }
 
-func NewCollector(client *govmomi.Client, logger *logrus.Logger, perfMetricFile string, logAvailableCounters bool, collectionLevel int, batchSizePerfEntitiesString string, batchSizePerfMetricsString string) (*PerfCollector, error) {
+func NewCollector(client *govmomi.Client, logger *logrus.Logger, perfMetricFile string, logAvailableCounters bool, collectionLevel int, batchSizePerfEntitiesString string, batchSizePerfMetricsString string, sampleMode string, store persist.Storer, counterStore persist.Storer, counterCacheTTL time.Duration) (*PerfCollector, error) {
 
 	batchSizePerfEntities, batchSizePerfMetrics, err := sanitizeArgs(batchSizePerfEntitiesString, batchSizePerfMetricsString)
 	if err != nil {
@@ -53,6 +80,11 @@ func NewCollector(client *govmomi.Client, logger *logrus.Logger, perfMetricFile
 		return nil, err
 	}
 
+	if err = sanitizeSampleMode(sampleMode); err != nil {
+		logger.WithError(err).Error("error while parsing args, not possible to collect perfMetrics")
+		return nil, err
+	}
+
 	perfManager := performance.NewManager(client.Client)
 
 	perfCollector := &PerfCollector{
@@ -62,6 +94,13 @@ func NewCollector(client *govmomi.Client, logger *logrus.Logger, perfMetricFile
 		collectionLevel:       collectionLevel,
 		batchSizePerfEntities: batchSizePerfEntities,
 		batchSizePerfMetrics:  batchSizePerfMetrics,
+		sampleMode:            sampleMode,
+		counterCache:          newCounterCache(counterStore, counterCacheTTL, client.ServiceContent.About),
+	}
+
+	// checkpoints are needed only when all the samples since the previous execution are requested
+	if sampleMode != SampleModeLatest {
+		perfCollector.checkpoints = newCheckpoints(store, client.ServiceContent.About.InstanceUuid)
 	}
 
 	err = perfCollector.retrieveCounterMetadata(logAvailableCounters)
@@ -69,6 +108,10 @@ func NewCollector(client *govmomi.Client, logger *logrus.Logger, perfMetricFile
 		logger.WithError(err).Errorf("failed to fetch available metrics from perfManager")
 		return nil, err
 	}
+	err = perfCollector.retrieveHistoricalIntervals()
+	if err != nil {
+		logger.WithError(err).Warn("failed to fetch historical intervals from perfManager, default intervals will be used")
+	}
 	err = perfCollector.parseConfigFile(perfMetricFile)
 	if err != nil {
 		logger.WithError(err).WithField("file", perfMetricFile).Errorf("failed to fetch data from performance config file")
@@ -102,6 +145,11 @@ func (c *PerfCollector) Collect(mos []types.ManagedObjectReference, metrics []ty
 					//When an intervalId is specified, the server tries to summarize the information for the specified intervalId.
 					//However, if that interval does not exist or has no data, the server summarizes the information using the best interval available.
 				}
+				// samples are returned starting from the last one reported in the previous execution (excluded)
+				if start := c.checkpoints.windowStart(ref); start != nil {
+					querySpec.MaxSample = 0
+					querySpec.StartTime = start
+				}
 				query.QuerySpec = append(query.QuerySpec, querySpec)
 			}
 
@@ -139,6 +187,12 @@ type perfEvaluer struct {
 	accumulator       accumulator
 }
 
+// perfSampleKey identifies the values returned for a counter in a specific sample
+type perfSampleKey struct {
+	counter string
+	sample  int
+}
+
 type accumulator struct {
 	Occurrences int64
 	Sum         int64
@@ -147,7 +201,7 @@ type accumulator struct {
 func (c *PerfCollector) processEntityMetrics(metricsValues *types.PerfEntityMetric, perfMetricsByRef map[types.ManagedObjectReference][]PerfMetric) {
 
 	// If for the same metrics multiple instances are returned we perform the average of the values
-	accumulateMetrics := map[string]*perfEvaluer{}
+	accumulateMetrics := map[perfSampleKey]*perfEvaluer{}
 
 	if metricsValues == nil {
 		return
@@ -155,15 +209,23 @@ func (c *PerfCollector) processEntityMetrics(metricsValues *types.PerfEntityMetr
 
 	for _, metricValue := range metricsValues.Value {
 
-		metricName, metricVal, err := c.extractValue(metricValue)
+		metricName, metricVals, err := c.extractValue(metricValue)
 		if err != nil {
 			c.logger.Debugf("extracting value %v", err)
 			continue
 		}
 
-		accumulateValues(accumulateMetrics, metricName, metricValue, metricVal)
+		for i, metricVal := range metricVals {
+			// MaxSamples is set to 1 but the API is retrieving multiple samples with the same value for historical interval metrics.
+			// We will take just first one unless all samples are requested.
+			if i > 0 && c.sampleMode != SampleModeAll && c.sampleMode != SampleModeSummary {
+				break
+			}
+			accumulateValues(accumulateMetrics, perfSampleKey{counter: metricName, sample: i}, metricValue, metricVal)
+		}
 	}
 
+	var entityMetrics []PerfMetric
 	for key, val := range accumulateMetrics {
 		var value int64
 
@@ -174,21 +236,36 @@ func (c *PerfCollector) processEntityMetrics(metricsValues *types.PerfEntityMetr
 			value = *val.instancelessValue
 		}
 
-		perfMetricsByRef[metricsValues.Entity] = append(perfMetricsByRef[metricsValues.Entity], PerfMetric{
-			Counter: key,
-			Value:   value,
+		var timestamp time.Time
+		if key.sample < len(metricsValues.SampleInfo) {
+			timestamp = metricsValues.SampleInfo[key.sample].Timestamp
+		}
+
+		entityMetrics = append(entityMetrics, PerfMetric{
+			Counter:   key.counter,
+			Value:     value,
+			Timestamp: timestamp,
 		})
 	}
 
+	if len(entityMetrics) == 0 {
+		return
+	}
+	if c.sampleMode == SampleModeSummary {
+		entityMetrics = summarize(entityMetrics)
+	}
+	perfMetricsByRef[metricsValues.Entity] = append(perfMetricsByRef[metricsValues.Entity], entityMetrics...)
+
+	c.checkpoints.update(metricsValues.Entity, metricsValues.SampleInfo)
 }
 
-func accumulateValues(accumulateMetrics map[string]*perfEvaluer, metricName string, metricValue types.BasePerfMetricSeries, metricVal int64) {
+func accumulateValues(accumulateMetrics map[perfSampleKey]*perfEvaluer, key perfSampleKey, metricValue types.BasePerfMetricSeries, metricVal int64) {
 	// This is a short-lived object, the purpose is to compute the average of the different performance metrics
 	// when more than one instance per entity returns a value
-	pe, ok := accumulateMetrics[metricName]
+	pe, ok := accumulateMetrics[key]
 	if !ok {
 		pe = &perfEvaluer{accumulator: accumulator{}}
-		accumulateMetrics[metricName] = pe
+		accumulateMetrics[key] = pe
 	}
 
 	if metricValue.GetPerfMetricSeries().Id.Instance != "" {
@@ -199,36 +276,55 @@ func accumulateValues(accumulateMetrics map[string]*perfEvaluer, metricName stri
 	}
 }
 
-func (c *PerfCollector) extractValue(metricValue types.BasePerfMetricSeries) (string, int64, error) {
+// extractValue returns the counter name and the values of all the samples of the series
+func (c *PerfCollector) extractValue(metricValue types.BasePerfMetricSeries) (string, []int64, error) {
 	metricValueSeries, ok2 := metricValue.(*types.PerfMetricIntSeries)
 	if !ok2 || metricValueSeries == nil {
-		return "", 0, fmt.Errorf("metricValue is not of type metricValueSeries or nil")
+		return "", nil, fmt.Errorf("metricValue is not of type metricValueSeries or nil")
 	}
 
-	name, ok := c.metricsAvaliableByID[metricValueSeries.Id.CounterId]
+	name, ok := c.counterName(metricValueSeries.Id.CounterId)
 	if !ok {
-		return "", 0, fmt.Errorf("perf metric Id: %v is not present in the map", metricValueSeries.Id.CounterId)
+		return "", nil, fmt.Errorf("perf metric Id: %v is not present in the map", metricValueSeries.Id.CounterId)
 	}
 
 	if metricValueSeries.Value == nil {
-		return "", 0, fmt.Errorf("vCenter returned no samples for the metric: %v", name)
+		return "", nil, fmt.Errorf("vCenter returned no samples for the metric: %v", name)
 	}
 
 	if len(metricValueSeries.Value) < 1 {
-		return "", 0, fmt.Errorf(" metric: %v is not containing at least one sample, this is not expected", name)
+		return "", nil, fmt.Errorf(" metric: %v is not containing at least one sample, this is not expected", name)
 	}
 
-	// MaxSamples is set to 1 but the API is retrieving multiple samples with the same value for historical interval metrics.
-	// We will take just first one.
-	return name, metricValueSeries.Value[0], nil
+	return name, metricValueSeries.Value, nil
 }
 
 func (c *PerfCollector) retrieveCounterMetadata(logAvailableCounters bool) error {
 	ctx := context.Background()
 
-	counters, err := c.perfManager.CounterInfo(ctx)
+	c.countersMutex.Lock()
+	defer c.countersMutex.Unlock()
+
+	counters, fromCache := c.counterCache.load()
+	var err error
+	if !fromCache {
+		counters, err = c.perfManager.CounterInfo(ctx)
+		if err == nil {
+			if err := c.counterCache.save(counters); err != nil {
+				c.logger.WithError(err).Warn("failed to cache perf counters, they will be fetched again in the next execution")
+			}
+		}
+	}
+	c.countersFromCache = fromCache
+	c.setCounters(counters, logAvailableCounters)
+	return err
+}
+
+// setCounters indexes the counter catalogue by ID and by name, countersMutex must be held
+func (c *PerfCollector) setCounters(counters []types.PerfCounterInfo, logAvailableCounters bool) {
 	c.metricsAvaliableByID = map[int32]string{}
 	c.metricsAvaliableByName = map[string]int32{}
+	c.countersByID = map[int32]types.PerfCounterInfo{}
 
 	if logAvailableCounters {
 		c.logger.Infof("LogAvailableCounters FLAG ON, printing all %d available counters", len(counters))
@@ -238,21 +334,21 @@ func (c *PerfCollector) retrieveCounterMetadata(logAvailableCounters bool) error
 		fullCounterName := perfCounter.GroupInfo.GetElementDescription().Key + "." + perfCounter.NameInfo.GetElementDescription().Key + "." + fmt.Sprint(perfCounter.RollupType)
 		c.metricsAvaliableByName[fullCounterName] = perfCounter.Key
 		c.metricsAvaliableByID[perfCounter.Key] = fullCounterName
+		c.countersByID[perfCounter.Key] = perfCounter
 
 		if logAvailableCounters {
 			c.logger.Infof("%s [%d] %v %d", fullCounterName, perfCounter.Level, perfCounter.NameInfo.GetElementDescription().Summary, perfCounter.Key)
 		}
 	}
-	return err
 }
 
-func (c *PerfCollector) parseConfigFile(fileName string) error {
+func readConfigFile(fileName string) (*ymlConfig, error) {
 	if _, err := os.Stat(fileName); os.IsNotExist(err) {
-		return fmt.Errorf("error loading configuration from file. Configuration file does not exist")
+		return nil, fmt.Errorf("error loading configuration from file. Configuration file does not exist")
 	}
 	configFile, err := os.Open(fileName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer configFile.Close()
 
@@ -260,16 +356,27 @@ func (c *PerfCollector) parseConfigFile(fileName string) error {
 
 	var cf ymlConfig
 	err = ymlParser.Decode(&cf)
+	if err != nil {
+		return nil, err
+	}
+	return &cf, nil
+}
+
+func (c *PerfCollector) parseConfigFile(fileName string) error {
+	cf, err := readConfigFile(fileName)
 	if err != nil {
 		return err
 	}
 
 	c.MetricDefinition = &perfMetricsIDs{
-		VM:                     c.buildPerMetricID(cf.VM),
-		ClusterComputeResource: c.buildPerMetricID(cf.ClusterComputeResource),
-		ResourcePool:           c.buildPerMetricID(cf.ResourcePool),
-		Datastore:              c.buildPerMetricID(cf.Datastore),
-		Host:                   c.buildPerMetricID(cf.Host),
+		VM:                          c.buildPerMetricID(cf.VM),
+		ClusterComputeResource:      c.buildPerMetricID(cf.ClusterComputeResource),
+		ResourcePool:                c.buildPerMetricID(cf.ResourcePool),
+		Datastore:                   c.buildPerMetricID(cf.Datastore),
+		Host:                        c.buildPerMetricID(cf.Host),
+		Network:                     c.buildPerMetricID(cf.Network),
+		DistributedVirtualPortgroup: c.buildPerMetricID(cf.DistributedVirtualPortgroup),
+		VirtualApp:                  c.buildPerMetricID(cf.VirtualApp),
 	}
 
 	return nil
@@ -277,14 +384,22 @@ func (c *PerfCollector) parseConfigFile(fileName string) error {
 
 func (c *PerfCollector) buildPerMetricID(countersByLevel map[string][]string) []types.PerfMetricId {
 	var tmp []types.PerfMetricId
-	maxLevel := fmt.Sprintf("level_%d", c.collectionLevel)
-	for level, metrics := range countersByLevel {
-		// compares strings es: level_2 > level_3
-		if level > maxLevel {
+	added := map[int32]bool{}
+	for levelKey, metrics := range countersByLevel {
+		level, err := parseLevel(levelKey)
+		if err != nil {
+			c.logger.WithError(err).Warn("ignoring performance metrics of invalid level")
+			continue
+		}
+		if level > c.collectionLevel {
 			continue
 		}
 		for _, metricName := range metrics {
 			if counterID, ok := c.metricsAvaliableByName[metricName]; ok {
+				if added[counterID] {
+					continue
+				}
+				added[counterID] = true
 				// For the instance property, specify an asterisk (“*”) to retrieve instance and aggregate data
 				// https://vdc-download.vmware.com/vmwb-repository/dcr-public/cdbbd51c-4824-4a1b-ad43-45df55a76a76/8cb3ed93-cac2-46aa-b329-db5a096af5bc/vsphere-web-services-sdk-67-programming-guide.pdf
 				pfi := types.PerfMetricId{CounterId: counterID, Instance: "*"}
@@ -295,25 +410,43 @@ func (c *PerfCollector) buildPerMetricID(countersByLevel map[string][]string) []
 			}
 		}
 	}
-	// limit the number of counters to avoid reach the 256 limit on events metrics
-	return tmp[:min(counterLimit, len(tmp))]
+	// levels are stored in a map, sorting the counters keeps the requested metrics stable between executions
+	sort.Slice(tmp, func(i, j int) bool {
+		return tmp[i].CounterId < tmp[j].CounterId
+	})
+	return tmp
 }
 
 type perfMetricsIDs struct {
-	Host                   []types.PerfMetricId
-	VM                     []types.PerfMetricId
-	ResourcePool           []types.PerfMetricId
-	ClusterComputeResource []types.PerfMetricId
-	Datastore              []types.PerfMetricId
+	Host                        []types.PerfMetricId
+	VM                          []types.PerfMetricId
+	ResourcePool                []types.PerfMetricId
+	ClusterComputeResource      []types.PerfMetricId
+	Datastore                   []types.PerfMetricId
+	Network                     []types.PerfMetricId
+	DistributedVirtualPortgroup []types.PerfMetricId
+	VirtualApp                  []types.PerfMetricId
 }
 
 //This struct is used to parse the config file
 type ymlConfig struct {
-	Host                   map[string][]string `yaml:"host"`
-	VM                     map[string][]string `yaml:"vm"`
-	ResourcePool           map[string][]string `yaml:"resourcePool"`
-	ClusterComputeResource map[string][]string `yaml:"clusterComputeResource"`
-	Datastore              map[string][]string `yaml:"datastore"`
+	Host                        map[string][]string `yaml:"host"`
+	VM                          map[string][]string `yaml:"vm"`
+	ResourcePool                map[string][]string `yaml:"resourcePool"`
+	ClusterComputeResource      map[string][]string `yaml:"clusterComputeResource"`
+	Datastore                   map[string][]string `yaml:"datastore"`
+	Network                     map[string][]string `yaml:"network"`
+	DistributedVirtualPortgroup map[string][]string `yaml:"distributedVirtualPortgroup"`
+	VirtualApp                  map[string][]string `yaml:"virtualApp"`
+}
+
+// parseLevel returns the numeric level of a level key of the metrics file, es: level_2 -> 2
+func parseLevel(levelKey string) (int, error) {
+	level, err := strconv.Atoi(strings.TrimPrefix(levelKey, levelPrefix))
+	if !strings.HasPrefix(levelKey, levelPrefix) || err != nil || level < minLevel || level > maxLevel {
+		return 0, fmt.Errorf("invalid level %q, expected %s%d to %s%d", levelKey, levelPrefix, minLevel, levelPrefix, maxLevel)
+	}
+	return level, nil
 }
 
 func min(a, b int) int {
@@ -344,3 +477,11 @@ func sanitizeArgs(batchSizePerfEntitiesString string, batchSizePerfMetricsString
 
 	return batchSizePerfEntities, batchSizePerfMetrics, nil
 }
+
+func sanitizeSampleMode(sampleMode string) error {
+	switch sampleMode {
+	case SampleModeLatest, SampleModeAll, SampleModeSummary:
+		return nil
+	}
+	return fmt.Errorf("invalid perf sample mode %q, supported values are: %s, %s, %s", sampleMode, SampleModeLatest, SampleModeAll, SampleModeSummary)
+}
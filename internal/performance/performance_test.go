@@ -9,7 +9,11 @@ import (
 
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/performance"
@@ -35,6 +39,7 @@ host:
   level_1:
     - cpu.coreUtilization.average
     - not.considered
+    - cpu.demand.average
   level_2:
     - cpu.demand.average
 vm:
@@ -42,6 +47,13 @@ vm:
     - cpu.demand.average
   level_3:
     - cpu.outoflevel
+distributedVirtualPortgroup:
+  level_1:
+    - cpu.demand.average
+virtualApp:
+  level_2:
+    - cpu.coreUtilization.average
+    - cpu.demand.average
 `)
 
 	tmpfile, err := ioutil.TempFile("", "config")
@@ -58,9 +70,14 @@ vm:
 	tmpfile.Close()
 
 	// - cpu.costop.summation is discarded since is not in c.metricsAvaliableByName
+	// - cpu.demand.average is requested only once even if it is listed in two levels
 	assert.Len(t, c.MetricDefinition.Host, 2)
+	assert.Equal(t, int32(1), c.MetricDefinition.Host[0].CounterId, "counters should be sorted")
 
 	assert.Len(t, c.MetricDefinition.VM, 1)
+	assert.Len(t, c.MetricDefinition.DistributedVirtualPortgroup, 1)
+	assert.Len(t, c.MetricDefinition.VirtualApp, 2)
+	assert.Empty(t, c.MetricDefinition.Network)
 }
 
 func TestPerfCollector_NewCollector(t *testing.T) {
@@ -96,7 +113,7 @@ vm:
 	_, err, c := startVcSim(t)
 	assert.NoError(t, err)
 
-	pc, err := NewCollector(c, logrus.New(), tmpfile.Name(), false, 2, "100", "50")
+	pc, err := NewCollector(c, logrus.New(), tmpfile.Name(), false, 2, "100", "50", SampleModeLatest, nil, nil, 0)
 	assert.NoError(t, err)
 	tmpfile.Close()
 	assert.Len(t, pc.MetricDefinition.Host, 2)
@@ -340,3 +357,289 @@ func TestSanitize(t *testing.T) {
 	_, _, err = sanitizeArgs("1", "0")
 	assert.Error(t, err)
 }
+
+func TestProcessEntityMetrics_SampleModes(t *testing.T) {
+	hostEntity := types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+	first := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(RealTimeInterval * time.Second)
+
+	pem := &types.PerfEntityMetric{
+		PerfEntityMetricBase: types.PerfEntityMetricBase{
+			Entity: hostEntity,
+		},
+		SampleInfo: []types.PerfSampleInfo{{Timestamp: first, Interval: RealTimeInterval}, {Timestamp: second, Interval: RealTimeInterval}},
+		Value: []types.BasePerfMetricSeries{
+			&types.PerfMetricIntSeries{
+				PerfMetricSeries: types.PerfMetricSeries{Id: types.PerfMetricId{CounterId: 1}},
+				Value:            []int64{10, 30},
+			},
+		},
+	}
+
+	tests := []struct {
+		mode string
+		want []PerfMetric
+	}{
+		{
+			mode: SampleModeLatest,
+			want: []PerfMetric{{Counter: "counter", Value: 10, Timestamp: first}},
+		},
+		{
+			mode: SampleModeAll,
+			want: []PerfMetric{{Counter: "counter", Value: 10, Timestamp: first}, {Counter: "counter", Value: 30, Timestamp: second}},
+		},
+		{
+			mode: SampleModeSummary,
+			want: []PerfMetric{
+				{Counter: "counter", Value: 20, Timestamp: second},
+				{Counter: "counter.min", Value: 10, Timestamp: second},
+				{Counter: "counter.max", Value: 30, Timestamp: second},
+				{Counter: "counter.p95", Value: 30, Timestamp: second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p := PerfCollector{
+				logger:               logrus.New(),
+				metricsAvaliableByID: map[int32]string{1: "counter"},
+				sampleMode:           tt.mode,
+			}
+			perfMetricsByRef := map[types.ManagedObjectReference][]PerfMetric{}
+			p.processEntityMetrics(pem, perfMetricsByRef)
+			assert.ElementsMatch(t, tt.want, perfMetricsByRef[hostEntity])
+		})
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	store := persist.NewInMemoryStore()
+	cp := newCheckpoints(store, "vcenter-a")
+
+	assert.Nil(t, cp.windowStart(ref), "only the latest sample is requested the first time")
+
+	last := time.Now().Add(-time.Minute).Truncate(time.Second)
+	cp.update(ref, []types.PerfSampleInfo{{Timestamp: last.Add(-RealTimeInterval * time.Second)}, {Timestamp: last}})
+	assert.Nil(t, cp.windowStart(ref), "checkpoints are not used until they are saved")
+
+	require.NoError(t, cp.save())
+	start := cp.windowStart(ref)
+	require.NotNil(t, start)
+	assert.True(t, last.Equal(*start))
+
+	cp.lastSampleByRef[ref] = time.Now().Add(-2 * maxSampleWindow)
+	require.NoError(t, cp.save())
+	assert.True(t, cp.windowStart(ref).After(time.Now().Add(-maxSampleWindow-time.Minute)), "the window is limited to maxSampleWindow")
+
+	other := newCheckpoints(store, "vcenter-b")
+	assert.Nil(t, other.windowStart(ref), "checkpoints of a different vCenter are not shared")
+
+	var nilCheckpoints *checkpoints
+	assert.Nil(t, nilCheckpoints.windowStart(ref))
+	assert.NotPanics(t, func() { nilCheckpoints.update(ref, nil) })
+}
+
+func TestIntervalID(t *testing.T) {
+	ctx, err, c := startVcSim(t)
+	require.NoError(t, err)
+
+	var vms []mo.VirtualMachine
+	var datastores []mo.Datastore
+	var hosts []mo.HostSystem
+	m := view.NewManager(c.Client)
+	cv, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine", "Datastore", "HostSystem"}, true)
+	require.NoError(t, err)
+	require.NoError(t, cv.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name"}, &vms))
+	require.NoError(t, cv.Retrieve(ctx, []string{"Datastore"}, []string{"name"}, &datastores))
+	require.NoError(t, cv.Retrieve(ctx, []string{"HostSystem"}, []string{"name"}, &hosts))
+
+	p := PerfCollector{
+		client:          c,
+		perfManager:     performance.NewManager(c.Client),
+		logger:          logrus.New(),
+		collectionLevel: 1,
+	}
+	require.NoError(t, p.retrieveHistoricalIntervals())
+
+	assert.Equal(t, int32(RealTimeInterval), p.IntervalID([]types.ManagedObjectReference{vms[0].Self}, 0), "vms support real-time stats")
+	assert.Equal(t, int32(FiveMinutesInterval), p.IntervalID([]types.ManagedObjectReference{datastores[0].Self}, 0), "datastores support only historical stats")
+
+	assert.Equal(t, int32(RealTimeInterval), p.IntervalID([]types.ManagedObjectReference{vms[1].Self}, 0), "interval is cached per entity type")
+	p.perfManager = nil
+	assert.Equal(t, int32(FiveMinutesInterval), p.IntervalID([]types.ManagedObjectReference{datastores[0].Self}, 0), "cached interval does not query the provider summary")
+	p.perfManager = performance.NewManager(c.Client)
+
+	notExisting := types.ManagedObjectReference{Type: "HostSystem", Value: "not-existing"}
+	assert.Equal(t, int32(42), p.IntervalID([]types.ManagedObjectReference{notExisting}, 42), "fallback is used when provider summary fails")
+	assert.Equal(t, int32(RealTimeInterval), p.IntervalID([]types.ManagedObjectReference{hosts[0].Self}, 42), "fallback is not cached when provider summary fails")
+
+	assert.Equal(t, int32(7), p.IntervalID(nil, 7))
+}
+
+func TestSelectInterval(t *testing.T) {
+	p := PerfCollector{
+		logger:          logrus.New(),
+		collectionLevel: 3,
+		historicalIntervals: []types.PerfInterval{
+			{Name: "Past Week", SamplingPeriod: 1800, Level: 1, Enabled: true},
+			{Name: "Disabled", SamplingPeriod: 60, Level: 4, Enabled: false},
+			{Name: "Past Day", SamplingPeriod: 600, Level: 2, Enabled: true},
+		},
+	}
+
+	realTime := &types.PerfProviderSummary{CurrentSupported: true, SummarySupported: true, RefreshRate: 20}
+	assert.Equal(t, int32(20), p.selectInterval("HostSystem", realTime, FiveMinutesInterval))
+
+	historical := &types.PerfProviderSummary{SummarySupported: true, RefreshRate: -1}
+	assert.Equal(t, int32(600), p.selectInterval("Datastore", historical, FiveMinutesInterval), "shortest enabled interval is used")
+
+	none := &types.PerfProviderSummary{}
+	assert.Equal(t, int32(FiveMinutesInterval), p.selectInterval("Datastore", none, FiveMinutesInterval))
+
+	p.historicalIntervals = nil
+	assert.Equal(t, int32(FiveMinutesInterval), p.selectInterval("Datastore", historical, FiveMinutesInterval))
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := parseLevel("level_2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, level)
+
+	for _, levelKey := range []string{"level_10", "level_0", "level_x", "2", "lvl_2"} {
+		_, err := parseLevel(levelKey)
+		assert.Error(t, err, levelKey)
+	}
+}
+
+func TestPerfCollector_ValidateConfigFile(t *testing.T) {
+	content := []byte(`
+host:
+  level_1:
+    - cpu.usage.average
+    - metric.not.available
+  level_10:
+    - cpu.demand.average
+vm:
+  level_1:
+    - cpu.usage.average
+  level_2:
+    - cpu.usage.average
+  level_3:
+    - cpu.demand.average
+`)
+
+	tmpfile, err := ioutil.TempFile("", "config")
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(tmpfile.Name())
+		assert.NoError(t, err)
+	}()
+	_, err = tmpfile.Write(content)
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	_, err, c := startVcSim(t)
+	require.NoError(t, err)
+
+	pc, err := NewCollector(c, logrus.New(), tmpfile.Name(), false, 2, "100", "50", SampleModeLatest, nil, nil, 0)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	problems, err := pc.ValidateConfigFile(tmpfile.Name(), &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, problems)
+	assert.Contains(t, out.String(), `# [host] counter metric.not.available is unknown to the vCenter`)
+	assert.Contains(t, out.String(), `# [host] invalid level "level_10"`)
+	assert.Contains(t, out.String(), `# [vm] counter cpu.usage.average is duplicated in level_1 and level_2`)
+	assert.Contains(t, out.String(), `# [vm] counter cpu.demand.average is listed in level_3, above perf_level 2`)
+
+	assert.Contains(t, out.String(), "\nvm:\n  level_1:\n")
+	assert.Contains(t, out.String(), "    - cpu.usage.average # [percent] ")
+}
+
+func TestCounterCache(t *testing.T) {
+	_, err, c := startVcSim(t)
+	require.NoError(t, err)
+
+	store := persist.NewInMemoryStore()
+	p := PerfCollector{
+		client:       c,
+		perfManager:  performance.NewManager(c.Client),
+		logger:       logrus.New(),
+		counterCache: newCounterCache(store, time.Hour, c.ServiceContent.About),
+	}
+	require.NoError(t, p.retrieveCounterMetadata(false))
+	assert.False(t, p.countersFromCache, "first execution fetches the counters from the vCenter")
+
+	// the perfManager is not used when the counters are cached
+	cached := PerfCollector{
+		client:       c,
+		logger:       logrus.New(),
+		counterCache: newCounterCache(store, time.Hour, c.ServiceContent.About),
+	}
+	require.NoError(t, cached.retrieveCounterMetadata(false))
+	assert.True(t, cached.countersFromCache)
+	assert.Equal(t, p.metricsAvaliableByName, cached.metricsAvaliableByName)
+	counterID := p.metricsAvaliableByName["cpu.usage.average"]
+	assert.Equal(t, p.countersByID[counterID].Level, cached.countersByID[counterID].Level)
+	assert.Equal(t, "percent", cached.countersByID[counterID].UnitInfo.GetElementDescription().Key)
+
+	// a different vCenter version does not use the same catalogue
+	about := c.ServiceContent.About
+	about.Version = "0.0.0"
+	other := PerfCollector{
+		client:       c,
+		perfManager:  performance.NewManager(c.Client),
+		logger:       logrus.New(),
+		counterCache: newCounterCache(store, time.Hour, about),
+	}
+	require.NoError(t, other.retrieveCounterMetadata(false))
+	assert.False(t, other.countersFromCache)
+
+	// expired entries are not used
+	expired := PerfCollector{
+		client:       c,
+		perfManager:  performance.NewManager(c.Client),
+		logger:       logrus.New(),
+		counterCache: newCounterCache(store, -time.Second, c.ServiceContent.About),
+	}
+	require.NoError(t, expired.retrieveCounterMetadata(false))
+	assert.False(t, expired.countersFromCache)
+}
+
+func TestCounterName_RefreshesOutdatedCache(t *testing.T) {
+	_, err, c := startVcSim(t)
+	require.NoError(t, err)
+
+	store := persist.NewInMemoryStore()
+	cache := newCounterCache(store, time.Hour, c.ServiceContent.About)
+	require.NoError(t, cache.save([]types.PerfCounterInfo{
+		{Key: 1, GroupInfo: &types.ElementDescription{Key: "old"}, NameInfo: &types.ElementDescription{Key: "counter"}, UnitInfo: &types.ElementDescription{}, RollupType: "average"},
+	}))
+
+	p := PerfCollector{
+		client:       c,
+		perfManager:  performance.NewManager(c.Client),
+		logger:       logrus.New(),
+		counterCache: cache,
+	}
+	require.NoError(t, p.retrieveCounterMetadata(false))
+	require.True(t, p.countersFromCache)
+
+	name, ok := p.counterName(1)
+	assert.True(t, ok)
+	assert.Equal(t, "old.counter.average", name, "known counters do not trigger a refresh")
+
+	counterID := int32(2)
+	name, ok = p.counterName(counterID)
+	assert.True(t, ok, "unknown counters trigger a refresh of the catalogue")
+	assert.NotEmpty(t, name)
+	assert.False(t, p.countersFromCache)
+
+	var refreshed []cachedCounter
+	_, err = store.Get(cache.key, &refreshed)
+	require.NoError(t, err)
+	assert.Greater(t, len(refreshed), 1, "the refreshed catalogue is cached")
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package performance
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// maxSampleWindow limits how old the requested samples can be, real-time samples are kept by vCenter only for one hour
+const maxSampleWindow = time.Hour
+
+// checkpoints keeps track of the timestamp of the last sample reported for each entity, so that the next execution
+// requests only the samples produced since then.
+type checkpoints struct {
+	store persist.Storer
+	// instanceUUID of the vCenter is part of the keys, since different vCenters can have entities with the same reference
+	instanceUUID string
+	mutex        sync.Mutex
+	// last sample collected in this execution, they are stored only when saved so that all the queries
+	// for the same entity in this execution use the same window
+	lastSampleByRef map[types.ManagedObjectReference]time.Time
+}
+
+func newCheckpoints(store persist.Storer, instanceUUID string) *checkpoints {
+	return &checkpoints{
+		store:           store,
+		instanceUUID:    instanceUUID,
+		lastSampleByRef: map[types.ManagedObjectReference]time.Time{},
+	}
+}
+
+func (cp *checkpoints) key(ref types.ManagedObjectReference) string {
+	return "perf_" + cp.instanceUUID + "_" + ref.Type + "_" + ref.Value
+}
+
+// windowStart returns the timestamp of the last sample reported for the entity in the previous execution.
+// If no sample was reported it returns nil, so only the latest sample is requested.
+func (cp *checkpoints) windowStart(ref types.ManagedObjectReference) *time.Time {
+	if cp == nil || cp.store == nil {
+		return nil
+	}
+
+	var ts int64
+	if _, err := cp.store.Get(cp.key(ref), &ts); err != nil {
+		return nil
+	}
+
+	start := time.Unix(0, ts)
+	if limit := time.Now().Add(-maxSampleWindow); start.Before(limit) {
+		start = limit
+	}
+	return &start
+}
+
+// update records the most recent sample returned for the entity
+func (cp *checkpoints) update(ref types.ManagedObjectReference, sampleInfo []types.PerfSampleInfo) {
+	if cp == nil {
+		return
+	}
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	for _, si := range sampleInfo {
+		if si.Timestamp.After(cp.lastSampleByRef[ref]) {
+			cp.lastSampleByRef[ref] = si.Timestamp
+		}
+	}
+}
+
+func (cp *checkpoints) save() error {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	for ref, ts := range cp.lastSampleByRef {
+		cp.store.Set(cp.key(ref), ts.UnixNano())
+	}
+	return cp.store.Save()
+}
+
+// SaveCheckpoints persists the timestamp of the last sample collected for each entity.
+// It has no effect if only the latest sample is collected.
+func (c *PerfCollector) SaveCheckpoints() error {
+	if c.checkpoints == nil || c.checkpoints.store == nil {
+		return nil
+	}
+	return c.checkpoints.save()
+}
+
+// summarize reduces all the samples of each counter to their avg, min, max and p95. The average is reported using the
+// counter name, as it happens when only the latest sample is collected, the others adding a .min, .max and .p95 suffix.
+// The timestamp of the summary is the one of the most recent sample.
+func summarize(perfMetrics []PerfMetric) []PerfMetric {
+	valuesByCounter := map[string][]int64{}
+	lastSampleByCounter := map[string]time.Time{}
+	for _, pm := range perfMetrics {
+		valuesByCounter[pm.Counter] = append(valuesByCounter[pm.Counter], pm.Value)
+		if pm.Timestamp.After(lastSampleByCounter[pm.Counter]) {
+			lastSampleByCounter[pm.Counter] = pm.Timestamp
+		}
+	}
+
+	var summary []PerfMetric
+	for counter, values := range valuesByCounter {
+		sort.Slice(values, func(i, j int) bool {
+			return values[i] < values[j]
+		})
+
+		var sum int64
+		for _, v := range values {
+			sum += v
+		}
+		// nearest-rank percentile
+		p95 := values[int(math.Ceil(0.95*float64(len(values))))-1]
+
+		ts := lastSampleByCounter[counter]
+		summary = append(summary,
+			PerfMetric{Counter: counter, Value: sum / int64(len(values)), Timestamp: ts},
+			PerfMetric{Counter: counter + ".min", Value: values[0], Timestamp: ts},
+			PerfMetric{Counter: counter + ".max", Value: values[len(values)-1], Timestamp: ts},
+			PerfMetric{Counter: counter + ".p95", Value: p95, Timestamp: ts},
+		)
+	}
+	return summary
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package performance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// configSection describes a section of the metrics file
+type configSection struct {
+	name     string
+	moType   string
+	interval int32 // interval used when it cannot be discovered from the vCenter
+	counters func(cf *ymlConfig) map[string][]string
+}
+
+var configSections = []configSection{
+	{name: "host", moType: "HostSystem", interval: RealTimeInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.Host }},
+	{name: "vm", moType: "VirtualMachine", interval: RealTimeInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.VM }},
+	{name: "resourcePool", moType: "ResourcePool", interval: FiveMinutesInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.ResourcePool }},
+	{name: "clusterComputeResource", moType: "ClusterComputeResource", interval: FiveMinutesInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.ClusterComputeResource }},
+	{name: "datastore", moType: "Datastore", interval: FiveMinutesInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.Datastore }},
+	{name: "network", moType: "Network", interval: FiveMinutesInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.Network }},
+	{name: "distributedVirtualPortgroup", moType: "DistributedVirtualPortgroup", interval: FiveMinutesInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.DistributedVirtualPortgroup }},
+	{name: "virtualApp", moType: "VirtualApp", interval: FiveMinutesInterval, counters: func(cf *ymlConfig) map[string][]string { return cf.VirtualApp }},
+}
+
+// ValidateConfigFile checks the metrics file against the counters available in the vCenter and writes to w the
+// problems found: invalid levels, unknown counters, counters above the collection level and duplicated counters.
+// Then, for each entity type, it writes the counters available grouped by level in the metrics file format.
+// It returns the number of problems found.
+func (c *PerfCollector) ValidateConfigFile(fileName string, w io.Writer) (int, error) {
+	cf, err := readConfigFile(fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Fprintf(w, "# Validating %s with perf_level %d\n", fileName, c.collectionLevel)
+	problems := 0
+	for _, section := range configSections {
+		for _, problem := range c.validateSection(section.counters(cf)) {
+			fmt.Fprintf(w, "# [%s] %s\n", section.name, problem)
+			problems++
+		}
+	}
+	if problems == 0 {
+		fmt.Fprintln(w, "# No problems found")
+	}
+
+	fmt.Fprintln(w, "#")
+	fmt.Fprintln(w, "# Performance counters available per entity type")
+	fmt.Fprintln(w, "#")
+	refs, err := c.findEntities()
+	if err != nil {
+		return problems, err
+	}
+	for _, section := range configSections {
+		ref, ok := refs[section.moType]
+		if !ok {
+			fmt.Fprintf(w, "# [%s] no %s found, available counters cannot be listed\n", section.name, section.moType)
+			continue
+		}
+
+		interval := c.IntervalID([]types.ManagedObjectReference{ref}, section.interval)
+		available, err := c.perfManager.AvailableMetric(context.Background(), ref, interval)
+		if err != nil {
+			fmt.Fprintf(w, "# [%s] failed to fetch available counters: %v\n", section.name, err)
+			continue
+		}
+		c.writeAvailableCounters(w, section.name, available)
+	}
+
+	return problems, nil
+}
+
+// validateSection returns the problems found in the counters of a section of the metrics file
+func (c *PerfCollector) validateSection(countersByLevel map[string][]string) []string {
+	var problems []string
+
+	// levels are checked in a stable order so the report is the same on every execution
+	var levelKeys []string
+	for levelKey := range countersByLevel {
+		levelKeys = append(levelKeys, levelKey)
+	}
+	sort.Strings(levelKeys)
+
+	listedIn := map[string]string{}
+	for _, levelKey := range levelKeys {
+		level, err := parseLevel(levelKey)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		for _, name := range countersByLevel[levelKey] {
+			if previous, ok := listedIn[name]; ok {
+				problems = append(problems, fmt.Sprintf("counter %s is duplicated in %s and %s", name, previous, levelKey))
+				continue
+			}
+			listedIn[name] = levelKey
+
+			counterID, ok := c.metricsAvaliableByName[name]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("counter %s is unknown to the vCenter", name))
+				continue
+			}
+			if level > c.collectionLevel {
+				problems = append(problems, fmt.Sprintf("counter %s is listed in %s, above perf_level %d, it will not be collected", name, levelKey, c.collectionLevel))
+				continue
+			}
+			if counter, ok := c.countersByID[counterID]; ok && int(counter.Level) > c.collectionLevel {
+				problems = append(problems, fmt.Sprintf("counter %s has level %d in the vCenter, above perf_level %d, it will have no data", name, counter.Level, c.collectionLevel))
+			}
+		}
+	}
+	return problems
+}
+
+// writeAvailableCounters writes the available counters grouped by level, with their unit and description.
+func (c *PerfCollector) writeAvailableCounters(w io.Writer, section string, available []types.PerfMetricId) {
+	countersByLevel := map[int][]types.PerfCounterInfo{}
+	added := map[int32]bool{}
+	for _, metricID := range available {
+		counter, ok := c.countersByID[metricID.CounterId]
+		if !ok || added[metricID.CounterId] {
+			continue
+		}
+		added[metricID.CounterId] = true
+
+		level := int(counter.Level)
+		if level < minLevel {
+			level = minLevel
+		}
+		countersByLevel[level] = append(countersByLevel[level], counter)
+	}
+
+	fmt.Fprintf(w, "%s:\n", section)
+	for level := minLevel; level <= maxLevel; level++ {
+		counters, ok := countersByLevel[level]
+		if !ok {
+			continue
+		}
+		sort.Slice(counters, func(i, j int) bool {
+			return c.metricsAvaliableByID[counters[i].Key] < c.metricsAvaliableByID[counters[j].Key]
+		})
+
+		fmt.Fprintf(w, "  %s%d:\n", levelPrefix, level)
+		for _, counter := range counters {
+			fmt.Fprintf(w, "    - %s # [%s] %s\n",
+				c.metricsAvaliableByID[counter.Key],
+				counter.UnitInfo.GetElementDescription().Key,
+				counter.NameInfo.GetElementDescription().Summary)
+		}
+	}
+}
+
+// findEntities returns one entity for each of the managed object types of the metrics file
+func (c *PerfCollector) findEntities() (map[string]types.ManagedObjectReference, error) {
+	ctx := context.Background()
+
+	var moTypes []string
+	for _, section := range configSections {
+		moTypes = append(moTypes, section.moType)
+	}
+
+	m := view.NewManager(c.client.Client)
+	cv, err := m.CreateContainerView(ctx, c.client.ServiceContent.RootFolder, moTypes, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := cv.Destroy(ctx)
+		if err != nil {
+			c.logger.WithError(err).Error("error while cleaning up container view")
+		}
+	}()
+
+	refs, err := cv.Find(ctx, moTypes, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := map[string]types.ManagedObjectReference{}
+	for _, ref := range refs {
+		if _, ok := entities[ref.Type]; !ok {
+			entities[ref.Type] = ref
+		}
+	}
+	return entities, nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strconv"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// addResourceAllocation adds the reservation, limit and shares of a cpu or memory allocation to the sample.
+// The prefix is the resource, es: cpu, and the unit is appended to reservation and limit, es: MHz.
+// A limit of -1 means unlimited.
+func addResourceAllocation(config *config.Config, ms *metric.Set, prefix string, unit string, allocation *types.ResourceAllocationInfo) {
+	if allocation == nil {
+		return
+	}
+	if allocation.Reservation != nil {
+		checkError(config.Logrus, ms.SetMetric(prefix+".reservation"+unit, *allocation.Reservation, metric.GAUGE))
+	}
+	if allocation.Limit != nil {
+		checkError(config.Logrus, ms.SetMetric(prefix+".limit"+unit, *allocation.Limit, metric.GAUGE))
+	}
+	if allocation.ExpandableReservation != nil {
+		checkError(config.Logrus, ms.SetMetric(prefix+".expandableReservation", strconv.FormatBool(*allocation.ExpandableReservation), metric.ATTRIBUTE))
+	}
+	if allocation.Shares != nil {
+		checkError(config.Logrus, ms.SetMetric(prefix+".shares", allocation.Shares.Shares, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(prefix+".sharesLevel", string(allocation.Shares.Level), metric.ATTRIBUTE))
+	}
+}
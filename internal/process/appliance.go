@@ -0,0 +1,67 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+)
+
+// vmon service states and startup types relevant for the appliance sample
+const (
+	applianceServiceStarted   = "STARTED"
+	applianceServiceStopped   = "STOPPED"
+	applianceServiceAutomatic = "AUTOMATIC"
+)
+
+// createApplianceSample adds the health of the vCenter appliance to the vCenter entity, with the usage of each
+// partition and the state of the vmon services
+func createApplianceSample(config *config.Config, e *integration.Entity) {
+	health := config.ApplianceHealth
+
+	ms := newMetricSet(config, e, "VSphere"+sampleTypeAppliance+"Sample")
+	if config.Args.DatacenterLocation != "" {
+		checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("vcenterHostname", config.VMWareClient.URL().Hostname(), metric.ATTRIBUTE))
+
+	for component, color := range health.Components {
+		checkError(config.Logrus, ms.SetMetric("health."+component, color, metric.ATTRIBUTE))
+	}
+
+	for partition, usage := range health.Partitions {
+		checkError(config.Logrus, ms.SetMetric("storage."+partition+".usedGiB", usage.UsedKB/(1<<20), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("storage."+partition+".totalGiB", usage.TotalKB/(1<<20), metric.GAUGE))
+		if usage.TotalKB > 0 {
+			checkError(config.Logrus, ms.SetMetric("storage."+partition+".usedPercentage", usage.UsedKB/usage.TotalKB*100, metric.GAUGE))
+		}
+	}
+
+	if len(health.Services) == 0 {
+		return
+	}
+	started := 0
+	// services expected to be running
+	var stoppedAutomatic []string
+	for name, service := range health.Services {
+		checkError(config.Logrus, ms.SetMetric("service."+name+".state", service.State, metric.ATTRIBUTE))
+		switch service.State {
+		case applianceServiceStarted:
+			started++
+		case applianceServiceStopped:
+			if service.StartupType == applianceServiceAutomatic {
+				stoppedAutomatic = append(stoppedAutomatic, name)
+			}
+		}
+	}
+	sort.Strings(stoppedAutomatic)
+	checkError(config.Logrus, ms.SetMetric("services.total", len(health.Services), metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("services.started", started, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("services.stoppedAutomatic", len(stoppedAutomatic), metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("services.stoppedAutomaticList", strings.Join(stoppedAutomatic, "|"), metric.ATTRIBUTE))
+}
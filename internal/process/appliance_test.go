@@ -0,0 +1,58 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_createVcenterSamples_HasApplianceSample(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		cfg := &config.Config{VMWareClient: vmClient, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.ApplianceHealth = model.NewApplianceHealth()
+		cfg.ApplianceHealth.Components["system"] = "green"
+		cfg.ApplianceHealth.Components["storage"] = "red"
+		cfg.ApplianceHealth.Partitions["seat"] = &model.PartitionUsage{UsedKB: 9 << 20, TotalKB: 10 << 20}
+		cfg.ApplianceHealth.Services["vpxd"] = model.ApplianceService{State: "STARTED", StartupType: "AUTOMATIC"}
+		cfg.ApplianceHealth.Services["vsphere-ui"] = model.ApplianceService{State: "STOPPED", StartupType: "AUTOMATIC"}
+		cfg.ApplianceHealth.Services["vmcam"] = model.ApplianceService{State: "STOPPED", StartupType: "MANUAL"}
+
+		// when
+		createVcenterSamples(cfg)
+
+		// then
+		var applianceSample map[string]interface{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] == "VSphereApplianceSample" {
+					applianceSample = ms.Metrics
+				}
+			}
+		}
+		require.NotNil(t, applianceSample)
+		assert.Equal(t, "green", applianceSample["health.system"])
+		assert.Equal(t, "red", applianceSample["health.storage"])
+		assert.Equal(t, float64(9), applianceSample["storage.seat.usedGiB"])
+		assert.Equal(t, float64(10), applianceSample["storage.seat.totalGiB"])
+		assert.Equal(t, float64(90), applianceSample["storage.seat.usedPercentage"])
+		assert.Equal(t, "STOPPED", applianceSample["service.vsphere-ui.state"])
+		assert.Equal(t, float64(3), applianceSample["services.total"])
+		assert.Equal(t, float64(1), applianceSample["services.started"])
+		assert.Equal(t, float64(1), applianceSample["services.stoppedAutomatic"])
+		assert.Equal(t, "vsphere-ui", applianceSample["services.stoppedAutomaticList"])
+	})
+}
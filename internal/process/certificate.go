@@ -0,0 +1,55 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	eventSDK "github.com/newrelic/infra-integrations-sdk/v3/data/event"
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+)
+
+const (
+	certificateEventCategory = "vSphereCertificate"
+	certificateStorePrefix   = "certificate_"
+)
+
+// addCertificate adds the certificate validity to the sample and sends an event to the entity each time the days
+// left before its expiration reach a new threshold. The owner is the name of the host or vCenter used in the event.
+func addCertificate(config *config.Config, e *integration.Entity, ms *metric.Set, cert *x509.Certificate, owner string) {
+	daysUntilExpiry := model.DaysUntilExpiry(cert, time.Now())
+
+	checkError(config.Logrus, ms.SetMetric("certificate.subject", cert.Subject.String(), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("certificate.issuer", cert.Issuer.String(), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("certificate.notBefore", cert.NotBefore.UTC().Format(time.RFC3339), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("certificate.notAfter", cert.NotAfter.UTC().Format(time.RFC3339), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("certificate.daysUntilExpiry", daysUntilExpiry, metric.GAUGE))
+
+	threshold, crossed := model.CrossedExpiryThreshold(config.ExpiryThresholds, daysUntilExpiry)
+	if !crossed || thresholdNotified(config, certificateStorePrefix+model.CertificateFingerprint(cert), threshold) {
+		return
+	}
+
+	summary := fmt.Sprintf("Certificate of %s expires in %d days", owner, daysUntilExpiry)
+	if daysUntilExpiry < 0 {
+		summary = fmt.Sprintf("Certificate of %s expired %d days ago", owner, -daysUntilExpiry)
+	}
+	ev := &eventSDK.Event{
+		Summary:  summary,
+		Category: certificateEventCategory,
+		Attributes: map[string]interface{}{
+			"certificate.owner":           owner,
+			"certificate.subject":         cert.Subject.String(),
+			"certificate.notAfter":        cert.NotAfter.UTC().Format(time.RFC3339),
+			"certificate.daysUntilExpiry": daysUntilExpiry,
+			"certificate.threshold":       threshold,
+		},
+	}
+	checkError(config.Logrus, e.AddEvent(ev))
+}
@@ -0,0 +1,76 @@
+package process
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_addCertificate_EventOncePerThreshold(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger(), ExpiryThresholds: []int{7, 30}, EventStore: persist.NewInMemoryStore()}
+	cfg.Integration, _ = integration.New("test", "dev")
+	e, err := cfg.Integration.Entity("host", "vsphere-host")
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		Raw:       []byte("certificate"),
+		Subject:   pkix.Name{CommonName: "esx01"},
+		Issuer:    pkix.Name{CommonName: "vmca"},
+		NotBefore: time.Now().Add(-365 * 24 * time.Hour),
+		NotAfter:  time.Now().Add(20*24*time.Hour + time.Hour),
+	}
+
+	// when
+	for i := 0; i < 2; i++ {
+		addCertificate(cfg, e, e.NewMetricSet("VSphereHostSample"), cert, "esx01")
+	}
+
+	// then
+	ms := e.Metrics[0].Metrics
+	assert.Equal(t, "CN=esx01", ms["certificate.subject"])
+	assert.Equal(t, "CN=vmca", ms["certificate.issuer"])
+	assert.Equal(t, float64(20), ms["certificate.daysUntilExpiry"])
+	require.Len(t, e.Events, 1)
+	assert.Equal(t, "Certificate of esx01 expires in 20 days", e.Events[0].Summary)
+	assert.Equal(t, 30, e.Events[0].Attributes["certificate.threshold"])
+
+	// a lower threshold is notified again
+	cert.NotAfter = time.Now().Add(5*24*time.Hour + time.Hour)
+	addCertificate(cfg, e, e.NewMetricSet("VSphereHostSample"), cert, "esx01")
+	require.Len(t, e.Events, 2)
+	assert.Equal(t, 7, e.Events[1].Attributes["certificate.threshold"])
+}
+
+func Test_createVcenterSamples_Certificate(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		vmClient, cert, err := client.NewWithCertificate(vc.URL().String(), "user", "pass", false, nil)
+		require.NoError(t, err)
+
+		// given
+		cfg := &config.Config{VMWareClient: vmClient, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true, VcenterCertificate: cert}
+		cfg.Integration, _ = integration.New("test", "dev")
+
+		// when
+		createVcenterSamples(cfg)
+
+		// then
+		require.Len(t, cfg.Integration.Entities, 1)
+		ms := cfg.Integration.Entities[0].Metrics[0].Metrics
+		assert.Equal(t, "VSphereVcenterSample", ms["event_type"])
+		assert.Equal(t, vc.URL().Hostname(), ms["vcenterHostname"])
+		assert.Equal(t, cert.NotAfter.UTC().Format(time.RFC3339), ms["certificate.notAfter"])
+		assert.NotNil(t, ms["certificate.daysUntilExpiry"])
+	})
+}
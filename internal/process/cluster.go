@@ -108,6 +108,8 @@ func createClusterSamples(config *config.Config) {
 			checkError(config.Logrus, ms.SetMetric("dasConfig.vmComponentProtecting", cluster.Configuration.DasConfig.VmComponentProtecting, metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("dasConfig.hbDatastoreCandidatePolicy", cluster.Configuration.DasConfig.HBDatastoreCandidatePolicy, metric.ATTRIBUTE))
 
+			addCustomAttributes(config, e, ms, &cluster.ManagedEntity)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(cluster.Self)
@@ -119,10 +121,11 @@ func createClusterSamples(config *config.Config) {
 			}
 			// Performance metrics
 			if config.PerfMetricsCollectionEnabled() {
-				perfMetrics := dc.GetPerfMetrics(cluster.Self)
-				for _, perfMetric := range perfMetrics {
-					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
-				}
+				addPerfMetrics(config, e, ms, entityTypeCluster, dc.GetPerfMetrics(cluster.Self))
+			}
+			// vSAN
+			if vsanCluster, ok := dc.VsanClusters[cluster.Self]; ok {
+				createVsanClusterSample(config, e, dc, cluster, vsanCluster)
 			}
 		}
 	}
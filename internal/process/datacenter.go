@@ -105,6 +105,8 @@ func createDatacenterSamples(config *config.Config) {
 		checkError(config.Logrus, ms.SetMetric("resourcePools", countResourcePools, metric.GAUGE))
 		checkError(config.Logrus, ms.SetMetric("clusters", len(dc.Clusters), metric.GAUGE))
 
+		addCustomAttributes(config, dcEntity, ms, &dc.Datacenter.ManagedEntity)
+
 		// Tags
 		if config.TagCollectionEnabled() {
 			tagsByCategory := config.TagCollector.GetTagsByCategories(dc.Datacenter.Self)
@@ -0,0 +1,98 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/nri-vsphere/internal/config"
+)
+
+func createDatastoreClusterSamples(config *config.Config) {
+	for _, dc := range config.Datacenters {
+		for _, pod := range dc.DatastoreClusters {
+
+			// filtering here will to avoid sending data to backend
+			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(pod.Self) {
+				continue
+			}
+
+			datacenterName := dc.Datacenter.Name
+			entityName := sanitizeEntityName(config, pod.Name, datacenterName)
+			e, ms, err := createNewEntityWithMetricSet(config, entityTypeDatastoreCluster, entityName, entityName)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("datastoreClusterName", entityName).Error("failed to create metricSet")
+				continue
+			}
+
+			if config.Args.DatacenterLocation != "" {
+				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+			}
+			if config.IsVcenterAPIType {
+				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+			}
+
+			checkError(config.Logrus, ms.SetMetric("name", pod.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("overallStatus", string(pod.OverallStatus), metric.ATTRIBUTE))
+			if pod.Summary != nil {
+				checkError(config.Logrus, ms.SetMetric("capacity", float64(pod.Summary.Capacity)/(1<<30), metric.GAUGE))
+				checkError(config.Logrus, ms.SetMetric("freeSpace", float64(pod.Summary.FreeSpace)/(1<<30), metric.GAUGE))
+			}
+
+			// Retrieving the list of datastores belonging to the datastore cluster
+			datastoreList := ""
+			for _, dr := range pod.ChildEntity {
+				if ds, ok := dc.Datastores[dr]; ok {
+					datastoreList += ds.Name + "|"
+				}
+			}
+			datastoreList = strings.TrimSuffix(datastoreList, "|")
+			checkError(config.Logrus, ms.SetMetric("datastoreList", datastoreList, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("datastoreCount", len(pod.ChildEntity), metric.GAUGE))
+
+			// Storage DRS
+			if pod.PodStorageDrsEntry != nil {
+				podConfig := pod.PodStorageDrsEntry.StorageDrsConfig.PodConfig
+				checkError(config.Logrus, ms.SetMetric("sdrsConfig.enabled", strconv.FormatBool(podConfig.Enabled), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("sdrsConfig.automationLevel", podConfig.DefaultVmBehavior, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("sdrsConfig.ioLoadBalanceEnabled", strconv.FormatBool(podConfig.IoLoadBalanceEnabled), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("sdrsConfig.loadBalanceInterval", podConfig.LoadBalanceInterval, metric.GAUGE))
+				if podConfig.SpaceLoadBalanceConfig != nil {
+					checkError(config.Logrus, ms.SetMetric("sdrsConfig.spaceThresholdMode", podConfig.SpaceLoadBalanceConfig.SpaceThresholdMode, metric.ATTRIBUTE))
+					checkError(config.Logrus, ms.SetMetric("sdrsConfig.spaceUtilizationThreshold", podConfig.SpaceLoadBalanceConfig.SpaceUtilizationThreshold, metric.GAUGE))
+					checkError(config.Logrus, ms.SetMetric("sdrsConfig.freeSpaceThresholdGB", podConfig.SpaceLoadBalanceConfig.FreeSpaceThresholdGB, metric.GAUGE))
+				}
+				if podConfig.IoLoadBalanceConfig != nil {
+					checkError(config.Logrus, ms.SetMetric("sdrsConfig.ioLatencyThreshold", podConfig.IoLoadBalanceConfig.IoLatencyThreshold, metric.GAUGE))
+					checkError(config.Logrus, ms.SetMetric("sdrsConfig.ioLoadImbalanceThreshold", podConfig.IoLoadBalanceConfig.IoLoadImbalanceThreshold, metric.GAUGE))
+				}
+
+				recommendations := pod.PodStorageDrsEntry.Recommendation
+				checkError(config.Logrus, ms.SetMetric("sdrs.pendingRecommendations", len(recommendations), metric.GAUGE))
+				reasons := ""
+				for _, r := range recommendations {
+					reasons += r.Reason + "|"
+				}
+				reasons = strings.TrimSuffix(reasons, "|")
+				if reasons != "" {
+					checkError(config.Logrus, ms.SetMetric("sdrs.pendingRecommendationReasons", reasons, metric.ATTRIBUTE))
+				}
+			}
+
+			addCustomAttributes(config, e, ms, &pod.ManagedEntity)
+
+			// Tags
+			if config.TagCollectionEnabled() {
+				tagsByCategory := config.TagCollector.GetTagsByCategories(pod.Self)
+				for k, v := range tagsByCategory {
+					checkError(config.Logrus, ms.SetMetric(tagsPrefix+k, v, metric.ATTRIBUTE))
+					// add tags to inventory due to the inventory workaround
+					addTagsToInventory(config, e, k, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createDatastoreClusterSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		finder := find.NewFinder(vc)
+		dc, err := finder.Datacenter(ctx, "DC0")
+		require.NoError(t, err)
+		folders, err := dc.Folders(ctx)
+		require.NoError(t, err)
+		pod, err := folders.DatastoreFolder.CreateStoragePod(ctx, "DC0_POD0")
+		require.NoError(t, err)
+		finder.SetDatacenter(dc)
+		ds, err := finder.Datastore(ctx, "LocalDS_0")
+		require.NoError(t, err)
+		task, err := pod.MoveInto(ctx, []types.ManagedObjectReference{ds.Reference()})
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+
+		// when
+		collect.Datastores(cfg)
+		collect.DatastoreClusters(cfg)
+		createDatastoreClusterSamples(cfg)
+		createDatastoreSamples(cfg)
+
+		// then
+		var podSample, dsSample map[string]interface{}
+		for _, e := range cfg.Integration.Entities {
+			switch e.Metrics[0].Metrics["event_type"] {
+			case "VSphereDatastoreClusterSample":
+				podSample = e.Metrics[0].Metrics
+			case "VSphereDatastoreSample":
+				if e.Metrics[0].Metrics["name"] == "LocalDS_0" {
+					dsSample = e.Metrics[0].Metrics
+				}
+			}
+		}
+		require.NotNil(t, podSample)
+		assert.Equal(t, "DC0_POD0", podSample["name"])
+		assert.Equal(t, "LocalDS_0", podSample["datastoreList"])
+		assert.Equal(t, float64(1), podSample["datastoreCount"])
+		assert.Equal(t, "true", podSample["sdrsConfig.enabled"])
+		assert.Equal(t, float64(0), podSample["sdrs.pendingRecommendations"])
+		require.NotNil(t, dsSample)
+		assert.Equal(t, "DC0_POD0", dsSample["datastoreClusterName"])
+		return nil
+	})
+}
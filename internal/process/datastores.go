@@ -42,6 +42,9 @@ func createDatastoreSamples(config *config.Config) {
 			}
 
 			checkError(config.Logrus, ms.SetMetric("name", ds.Summary.Name, metric.ATTRIBUTE))
+			if pod, ok := dc.FindDatastoreCluster(ds.Self); ok {
+				checkError(config.Logrus, ms.SetMetric("datastoreClusterName", pod.Name, metric.ATTRIBUTE))
+			}
 			checkError(config.Logrus, ms.SetMetric("fileSystemType", ds.Summary.Type, metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("overallStatus", string(ds.OverallStatus), metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("accessible", fmt.Sprintf("%t", ds.Summary.Accessible), metric.ATTRIBUTE))
@@ -60,6 +63,8 @@ func createDatastoreSamples(config *config.Config) {
 				}
 			}
 
+			addCustomAttributes(config, e, ms, &ds.ManagedEntity)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(ds.Self)
@@ -72,10 +77,7 @@ func createDatastoreSamples(config *config.Config) {
 
 			// Performance metrics
 			if config.PerfMetricsCollectionEnabled() {
-				perfMetrics := dc.GetPerfMetrics(ds.Self)
-				for _, perfMetric := range perfMetrics {
-					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
-				}
+				addPerfMetrics(config, e, ms, entityTypeDatastore, dc.GetPerfMetrics(ds.Self))
 			}
 		}
 	}
@@ -0,0 +1,78 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Host inventory categories, attributes are reported as <category>.<item>
+const (
+	hostInventoryProduct  = "product"
+	hostInventoryHardware = "hardware"
+	hostInventoryBios     = "bios"
+)
+
+// addHostInventory adds the ESXi product, hardware and bios info of the host to its inventory and sample.
+// Nothing is reported if the inventory is disabled.
+func addHostInventory(config *config.Config, e *integration.Entity, ms *metric.Set, host *mo.HostSystem) {
+	if !config.Args.HasInventory() {
+		return
+	}
+
+	if host.Config != nil {
+		product := host.Config.Product
+		setHostInventoryItem(config, e, ms, hostInventoryProduct, "name", product.Name)
+		setHostInventoryItem(config, e, ms, hostInventoryProduct, "fullName", product.FullName)
+		setHostInventoryItem(config, e, ms, hostInventoryProduct, "version", product.Version)
+		setHostInventoryItem(config, e, ms, hostInventoryProduct, "build", product.Build)
+		setHostInventoryItem(config, e, ms, hostInventoryProduct, "patchLevel", product.PatchLevel)
+	}
+
+	if hw := host.Summary.Hardware; hw != nil {
+		setHostInventoryItem(config, e, ms, hostInventoryHardware, "vendor", hw.Vendor)
+		setHostInventoryItem(config, e, ms, hostInventoryHardware, "model", hw.Model)
+		setHostInventoryItem(config, e, ms, hostInventoryHardware, "cpuModel", hw.CpuModel)
+		for _, info := range hw.OtherIdentifyingInfo {
+			if info.IdentifierType == nil {
+				continue
+			}
+			switch info.IdentifierType.GetElementDescription().Key {
+			case string(types.HostSystemIdentificationInfoIdentifierAssetTag):
+				setHostInventoryItem(config, e, ms, hostInventoryHardware, "assetTag", strings.TrimSpace(info.IdentifierValue))
+			case string(types.HostSystemIdentificationInfoIdentifierServiceTag):
+				setHostInventoryItem(config, e, ms, hostInventoryHardware, "serviceTag", strings.TrimSpace(info.IdentifierValue))
+			case string(types.HostSystemIdentificationInfoIdentifierSerialNumberTag):
+				setHostInventoryItem(config, e, ms, hostInventoryHardware, "serialNumber", strings.TrimSpace(info.IdentifierValue))
+			}
+		}
+	}
+
+	if host.Hardware != nil {
+		// the system serial number is available from vSphere 7.0.3, it takes precedence over the identifying info
+		setHostInventoryItem(config, e, ms, hostInventoryHardware, "serialNumber", host.Hardware.SystemInfo.SerialNumber)
+		if bios := host.Hardware.BiosInfo; bios != nil {
+			setHostInventoryItem(config, e, ms, hostInventoryBios, "version", bios.BiosVersion)
+			setHostInventoryItem(config, e, ms, hostInventoryBios, "vendor", bios.Vendor)
+			if bios.ReleaseDate != nil {
+				setHostInventoryItem(config, e, ms, hostInventoryBios, "releaseDate", bios.ReleaseDate.Format("2006-01-02"))
+			}
+		}
+	}
+}
+
+// setHostInventoryItem sets the inventory item and the attribute, empty values are skipped
+func setHostInventoryItem(config *config.Config, e *integration.Entity, ms *metric.Set, category string, item string, value string) {
+	if value == "" {
+		return
+	}
+	checkError(config.Logrus, e.SetInventoryItem(category, item, value))
+	checkError(config.Logrus, ms.SetMetric(category+"."+item, value, metric.ATTRIBUTE))
+}
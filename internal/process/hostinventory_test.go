@@ -0,0 +1,70 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_createHostSamples_HasInventory(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+		vm := view.NewManager(vc)
+
+		tests := []struct {
+			name          string
+			onlyMetrics   bool
+			wantInventory bool
+		}{
+			{name: "InventoryEnabled", wantInventory: true},
+			{name: "OnlyMetrics", onlyMetrics: true, wantInventory: false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// given
+				cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+				cfg.Args.Metrics = tt.onlyMetrics
+				cfg.Integration, _ = integration.New("test", "dev")
+				cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+
+				// when
+				collect.Hosts(cfg)
+				createHostSamples(cfg)
+
+				// then
+				require.NotEmpty(t, cfg.Integration.Entities)
+				for _, e := range cfg.Integration.Entities {
+					sample := e.Metrics[0].Metrics
+					product, hasProduct := e.Inventory.Item("product")
+					bios, hasBios := e.Inventory.Item("bios")
+					if !tt.wantInventory {
+						assert.False(t, hasProduct)
+						assert.False(t, hasBios)
+						assert.NotContains(t, sample, "product.version")
+						continue
+					}
+					require.True(t, hasProduct)
+					assert.Equal(t, "8.0.2", product["version"])
+					assert.Equal(t, "21997540", product["build"])
+					assert.Equal(t, "8.0.2", sample["product.version"])
+					require.True(t, hasBios)
+					assert.Equal(t, "6.00", bios["version"])
+					assert.Equal(t, "VMware, Inc.", sample["hardware.vendor"])
+					assert.Contains(t, sample, "hardware.cpuModel")
+				}
+			})
+		}
+		return nil
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// nicType values of VSphereHostNicSample
+const (
+	nicTypePhysical = "physical"
+	nicTypeVmkernel = "vmkernel"
+)
+
+// createHostNicSamples adds a sample to the host entity for each physical nic and vmkernel adapter
+func createHostNicSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem, datacenterName string) {
+	if host.Config == nil || host.Config.Network == nil {
+		return
+	}
+	network := host.Config.Network
+
+	// virtual switches and distributed switches the physical nics are uplinks of
+	switchByPnic := map[string]string{}
+	for _, vswitch := range network.Vswitch {
+		for _, pnic := range vswitch.Pnic {
+			switchByPnic[pnic] = vswitch.Name
+		}
+	}
+	dvsByUUID := map[string]string{}
+	for _, proxySwitch := range network.ProxySwitch {
+		dvsByUUID[proxySwitch.DvsUuid] = proxySwitch.DvsName
+		for _, pnic := range proxySwitch.Pnic {
+			switchByPnic[pnic] = proxySwitch.DvsName
+		}
+	}
+	switchByPortgroup := map[string]string{}
+	for _, pg := range network.Portgroup {
+		switchByPortgroup[pg.Spec.Name] = pg.Spec.VswitchName
+	}
+
+	for _, pnic := range network.Pnic {
+		ms := newHostNicMetricSet(config, e, host, datacenterName, pnic.Device, nicTypePhysical)
+		checkError(config.Logrus, ms.SetMetric("driver", pnic.Driver, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("macAddress", pnic.Mac, metric.ATTRIBUTE))
+		// link speed is not set when the link is down
+		checkError(config.Logrus, ms.SetMetric("linkUp", strconv.FormatBool(pnic.LinkSpeed != nil), metric.ATTRIBUTE))
+		if pnic.LinkSpeed != nil {
+			checkError(config.Logrus, ms.SetMetric("linkSpeedMb", pnic.LinkSpeed.SpeedMb, metric.GAUGE))
+			duplex := "half"
+			if pnic.LinkSpeed.Duplex {
+				duplex = "full"
+			}
+			checkError(config.Logrus, ms.SetMetric("duplex", duplex, metric.ATTRIBUTE))
+		}
+		if s, ok := switchByPnic[pnic.Key]; ok {
+			checkError(config.Logrus, ms.SetMetric("virtualSwitch", s, metric.ATTRIBUTE))
+		}
+	}
+
+	servicesByVnic := vmkernelServices(host.Config.VirtualNicManagerInfo)
+	for _, vnic := range network.Vnic {
+		ms := newHostNicMetricSet(config, e, host, datacenterName, vnic.Device, nicTypeVmkernel)
+		checkError(config.Logrus, ms.SetMetric("macAddress", vnic.Spec.Mac, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("mtu", vnic.Spec.Mtu, metric.GAUGE))
+		if vnic.Spec.Ip != nil {
+			checkError(config.Logrus, ms.SetMetric("ipAddress", vnic.Spec.Ip.IpAddress, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("subnetMask", vnic.Spec.Ip.SubnetMask, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("dhcp", strconv.FormatBool(vnic.Spec.Ip.Dhcp), metric.ATTRIBUTE))
+		}
+		if vnic.Portgroup != "" {
+			checkError(config.Logrus, ms.SetMetric("portgroup", vnic.Portgroup, metric.ATTRIBUTE))
+			if s, ok := switchByPortgroup[vnic.Portgroup]; ok {
+				checkError(config.Logrus, ms.SetMetric("virtualSwitch", s, metric.ATTRIBUTE))
+			}
+		} else if dvp := vnic.Spec.DistributedVirtualPort; dvp != nil {
+			if s, ok := dvsByUUID[dvp.SwitchUuid]; ok {
+				checkError(config.Logrus, ms.SetMetric("virtualSwitch", s, metric.ATTRIBUTE))
+			}
+		}
+		services := servicesByVnic[vnic.Key]
+		sort.Strings(services)
+		checkError(config.Logrus, ms.SetMetric("services", strings.Join(services, "|"), metric.ATTRIBUTE))
+	}
+}
+
+func newHostNicMetricSet(config *config.Config, e *integration.Entity, host *mo.HostSystem, datacenterName string, device string, nicType string) *metric.Set {
+	ms := newMetricSet(config, e, "VSphere"+sampleTypeHostNic+"Sample")
+	if config.IsVcenterAPIType {
+		checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+	}
+	if config.Args.DatacenterLocation != "" {
+		checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("hypervisorHostname", host.Summary.Config.Name, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("device", device, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("nicType", nicType, metric.ATTRIBUTE))
+	return ms
+}
+
+// vmkernelServices returns the services enabled on each vmkernel adapter, es: management, vmotion, vsan
+func vmkernelServices(info *types.HostVirtualNicManagerInfo) map[string][]string {
+	servicesByVnic := map[string][]string{}
+	if info == nil {
+		return servicesByVnic
+	}
+	for _, netConfig := range info.NetConfig {
+		for _, selected := range netConfig.SelectedVnic {
+			// selected vnics are in the form <nicType>.<vnic key>
+			vnicKey := strings.TrimPrefix(selected, netConfig.NicType+".")
+			servicesByVnic[vnicKey] = append(servicesByVnic[vnicKey], netConfig.NicType)
+		}
+	}
+	return servicesByVnic
+}
@@ -0,0 +1,70 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_createHostSamples_HasNicSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		dc := getDatacenter(ctx, vm)
+		cfg.Datacenters = append(cfg.Datacenters, dc)
+		collect.Hosts(cfg)
+
+		for _, host := range dc.Hosts {
+			// vmnic1 link is down
+			host.Config.Network.Pnic[1].LinkSpeed = nil
+		}
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		nicSamples := map[string]map[string]interface{}{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] == "VSphereHostNicSample" {
+					nicSamples[ms.Metrics["device"].(string)] = ms.Metrics
+				}
+			}
+		}
+		require.Contains(t, nicSamples, "vmnic0")
+		assert.Equal(t, "physical", nicSamples["vmnic0"]["nicType"])
+		assert.Equal(t, "true", nicSamples["vmnic0"]["linkUp"])
+		assert.Equal(t, float64(10000), nicSamples["vmnic0"]["linkSpeedMb"])
+		assert.Equal(t, "full", nicSamples["vmnic0"]["duplex"])
+		assert.Equal(t, "nvmxnet3", nicSamples["vmnic0"]["driver"])
+		assert.Equal(t, "vSwitch0", nicSamples["vmnic0"]["virtualSwitch"])
+
+		require.Contains(t, nicSamples, "vmnic1")
+		assert.Equal(t, "false", nicSamples["vmnic1"]["linkUp"])
+		assert.NotContains(t, nicSamples["vmnic1"], "linkSpeedMb")
+
+		require.Contains(t, nicSamples, "vmk0")
+		assert.Equal(t, "vmkernel", nicSamples["vmk0"]["nicType"])
+		assert.Equal(t, "127.0.0.1", nicSamples["vmk0"]["ipAddress"])
+		assert.Equal(t, float64(1500), nicSamples["vmk0"]["mtu"])
+		assert.Equal(t, "Management Network", nicSamples["vmk0"]["portgroup"])
+		assert.Equal(t, "vSwitch0", nicSamples["vmk0"]["virtualSwitch"])
+		assert.Equal(t, "management", nicSamples["vmk0"]["services"])
+		return nil
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
 
 	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
 )
@@ -75,6 +76,22 @@ func createHostSamples(config *config.Config) {
 			checkError(config.Logrus, ms.SetMetric("standbyMode", host.Runtime.StandbyMode, metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("cryptoState", host.Runtime.CryptoState, metric.ATTRIBUTE))
 
+			createHostSecuritySample(config, e, host, datacenterName)
+
+			if host.Config != nil && len(host.Config.Certificate) > 0 {
+				cert, err := model.ParseCertificate(host.Config.Certificate)
+				if err != nil {
+					config.Logrus.WithError(err).WithField("hostName", entityName).Warn("failed to parse host certificate")
+				} else {
+					addCertificate(config, e, ms, cert, hostConfigName)
+				}
+			}
+
+			// hardware health
+			if healthStatus := createHostSensorSamples(config, e, host, datacenterName); healthStatus != "" {
+				checkError(config.Logrus, ms.SetMetric("hardwareHealthStatus", healthStatus, metric.ATTRIBUTE))
+			}
+
 			resourcePools := dc.FindResourcePools(host.Parent.Reference())
 			resourcePoolList := ""
 			for _, rp := range resourcePools {
@@ -100,8 +117,10 @@ func createHostSamples(config *config.Config) {
 			}
 			networkList = strings.TrimSuffix(networkList, "|")
 			checkError(config.Logrus, ms.SetMetric("networkNameList", networkList, metric.ATTRIBUTE))
+			createHostNicSamples(config, e, host, datacenterName)
 
 			checkError(config.Logrus, ms.SetMetric("uuid", host.Summary.Hardware.Uuid, metric.ATTRIBUTE))
+			addHostInventory(config, e, ms, host)
 
 			// memory
 			memoryTotal := host.Summary.Hardware.MemorySize / (1 << 20)
@@ -149,6 +168,9 @@ func createHostSamples(config *config.Config) {
 				}
 			}
 			checkError(config.Logrus, ms.SetMetric("disk.totalMiB", diskTotalMiB, metric.GAUGE))
+			createHostStorageDeviceSamples(config, e, host, datacenterName)
+
+			addCustomAttributes(config, e, ms, &host.ManagedEntity)
 
 			// Tags
 			if config.TagCollectionEnabled() {
@@ -158,12 +180,16 @@ func createHostSamples(config *config.Config) {
 					// add tags to inventory due to the inventory workaround
 					addTagsToInventory(config, e, k, v)
 				}
+				for k, v := range config.TagCollector.GetInheritedTagsByCategories(host.Self) {
+					checkError(config.Logrus, ms.SetMetric(inheritedTagsPrefix+k, v, metric.ATTRIBUTE))
+				}
 			}
 			// Performance metrics
 			if config.PerfMetricsCollectionEnabled() {
-				perfMetrics := dc.GetPerfMetrics(host.Self)
-				for _, perfMetric := range perfMetrics {
-					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
+				if reason := model.HostPerfSkippedReason(host); reason != "" {
+					checkError(config.Logrus, ms.SetMetric("perfCollectionSkippedReason", reason, metric.ATTRIBUTE))
+				} else {
+					addPerfMetrics(config, e, ms, entityTypeHost, dc.GetPerfMetrics(host.Self))
 				}
 			}
 
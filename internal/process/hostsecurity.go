@@ -0,0 +1,81 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// createHostSecuritySample adds the security posture sample to the host entity, with the hardening checks of the
+// baseline failed by the host
+func createHostSecuritySample(config *config.Config, e *integration.Entity, host *mo.HostSystem, datacenterName string) {
+	// disconnected and not responding hosts have no config, every check depending on it would fail
+	if host.Config == nil {
+		config.Logrus.WithField("hostName", host.Summary.Config.Name).Debug("host config not available, skipping security sample")
+		return
+	}
+
+	hs := model.NewHostSecurity(host)
+
+	ms := newMetricSet(config, e, "VSphere"+sampleTypeHostSecurity+"Sample")
+	if config.IsVcenterAPIType {
+		checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+	}
+	if config.Args.DatacenterLocation != "" {
+		checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("hypervisorHostname", host.Summary.Config.Name, metric.ATTRIBUTE))
+
+	checkError(config.Logrus, ms.SetMetric("lockdownMode", hs.LockdownMode, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("ssh.running", strconv.FormatBool(hs.SSHRunning), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("ssh.policy", hs.SSHPolicy, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("esxiShell.running", strconv.FormatBool(hs.ESXiShellRunning), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("esxiShell.policy", hs.ESXiShellPolicy, metric.ATTRIBUTE))
+
+	checkError(config.Logrus, ms.SetMetric("ntp.servers", strings.Join(hs.NTPServers, "|"), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("ntp.running", strconv.FormatBool(hs.NTPRunning), metric.ATTRIBUTE))
+	if hs.NTPSynced != nil {
+		checkError(config.Logrus, ms.SetMetric("ntp.synced", strconv.FormatBool(*hs.NTPSynced), metric.ATTRIBUTE))
+	}
+
+	if hs.FirewallIncomingBlocked != nil {
+		checkError(config.Logrus, ms.SetMetric("firewall.incomingBlocked", strconv.FormatBool(*hs.FirewallIncomingBlocked), metric.ATTRIBUTE))
+	}
+	if hs.FirewallOutgoingBlocked != nil {
+		checkError(config.Logrus, ms.SetMetric("firewall.outgoingBlocked", strconv.FormatBool(*hs.FirewallOutgoingBlocked), metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("syslog.logHost", hs.SyslogHost, metric.ATTRIBUTE))
+
+	if hs.TPMSupported != nil {
+		checkError(config.Logrus, ms.SetMetric("tpm.supported", strconv.FormatBool(*hs.TPMSupported), metric.ATTRIBUTE))
+	}
+	if hs.TPMVersion != "" {
+		checkError(config.Logrus, ms.SetMetric("tpm.version", hs.TPMVersion, metric.ATTRIBUTE))
+	}
+	if hs.TPMAttestationStatus != "" {
+		checkError(config.Logrus, ms.SetMetric("tpm.attestationStatus", hs.TPMAttestationStatus, metric.ATTRIBUTE))
+	}
+	if hs.SecureBootRequired != nil {
+		checkError(config.Logrus, ms.SetMetric("secureBoot.required", strconv.FormatBool(*hs.SecureBootRequired), metric.ATTRIBUTE))
+	}
+
+	// Hardening checks
+	baseline := config.SecurityBaseline
+	if baseline == nil {
+		baseline = model.DefaultSecurityBaseline()
+	}
+	checks, failed := baseline.Evaluate(hs)
+	checkError(config.Logrus, ms.SetMetric("hardening.checks", checks, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("hardening.failedChecks", len(failed), metric.GAUGE))
+	if len(failed) > 0 {
+		checkError(config.Logrus, ms.SetMetric("hardening.failedCheckList", strings.Join(failed, "|"), metric.ATTRIBUTE))
+	}
+}
@@ -0,0 +1,73 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createHostSamples_HasSecuritySample(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+		collect.Hosts(cfg)
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		var securitySample map[string]interface{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] == "VSphereHostSecuritySample" {
+					securitySample = ms.Metrics
+				}
+			}
+		}
+		require.NotNil(t, securitySample)
+		assert.Equal(t, "lockdownDisabled", securitySample["lockdownMode"])
+		assert.Equal(t, "false", securitySample["ssh.running"])
+		assert.Equal(t, "off", securitySample["ssh.policy"])
+		assert.Equal(t, "false", securitySample["esxiShell.running"])
+		assert.Equal(t, "false", securitySample["ntp.running"])
+		assert.Equal(t, "true", securitySample["firewall.incomingBlocked"])
+		// the simulated hosts are not in lockdown mode and have neither ntp nor syslog configured
+		assert.Equal(t, float64(7), securitySample["hardening.checks"])
+		assert.Equal(t, float64(4), securitySample["hardening.failedChecks"])
+		assert.Equal(t, "lockdownMode|ntpConfigured|ntpRunning|syslogConfigured", securitySample["hardening.failedCheckList"])
+		return nil
+	})
+}
+
+func Test_createHostSecuritySample_SkipsHostsWithoutConfig(t *testing.T) {
+	// given
+	cfg := &config.Config{Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+	cfg.Integration, _ = integration.New("test", "dev")
+	e, err := cfg.Integration.Entity("host", "vsphere-host")
+	require.NoError(t, err)
+	host := &mo.HostSystem{Summary: types.HostListSummary{Config: types.HostConfigSummary{Name: "host"}}}
+
+	// when
+	createHostSecuritySample(cfg, e, host, "DC0")
+
+	// then
+	assert.Empty(t, e.Metrics, "disconnected hosts would fail every check")
+}
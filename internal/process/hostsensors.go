@@ -0,0 +1,109 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"math"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Hardware components reported as sensors, numeric sensors report their own type
+const (
+	sensorTypeCPU     = "cpu"
+	sensorTypeMemory  = "memory"
+	sensorTypeStorage = "storage"
+)
+
+// healthSeverity orders the health states reported by the host health system, the rolled-up status is the most severe one
+var healthSeverity = map[string]int{
+	string(types.HostNumericSensorHealthStateGreen):   0,
+	string(types.HostNumericSensorHealthStateUnknown): 1,
+	string(types.HostNumericSensorHealthStateYellow):  2,
+	string(types.HostNumericSensorHealthStateRed):     3,
+}
+
+// createHostSensorSamples adds a sample to the host entity for each numeric sensor and hardware component reported by
+// the host health system. It returns the rolled-up hardware health status, empty if the host reports no health data.
+func createHostSensorSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem, datacenterName string) string {
+	if host.Runtime.HealthSystemRuntime == nil {
+		return ""
+	}
+	healthStatus := ""
+	rollUp := func(state string) {
+		if state == "" {
+			return
+		}
+		if healthStatus == "" || healthSeverity[state] > healthSeverity[healthStatus] {
+			healthStatus = state
+		}
+	}
+
+	if info := host.Runtime.HealthSystemRuntime.SystemHealthInfo; info != nil {
+		for _, sensor := range info.NumericSensorInfo {
+			ms := newHostSensorMetricSet(config, e, host, datacenterName, sensor.Name, sensor.SensorType, sensor.HealthState)
+			if sensor.Id != "" {
+				checkError(config.Logrus, ms.SetMetric("sensorId", sensor.Id, metric.ATTRIBUTE))
+			}
+			// the actual reading is the current reading multiplied by 10 raised to the unit modifier
+			checkError(config.Logrus, ms.SetMetric("reading", float64(sensor.CurrentReading)*math.Pow10(int(sensor.UnitModifier)), metric.GAUGE))
+			if sensor.BaseUnits != "" {
+				checkError(config.Logrus, ms.SetMetric("baseUnits", sensor.BaseUnits, metric.ATTRIBUTE))
+			}
+			if sensor.RateUnits != "" {
+				checkError(config.Logrus, ms.SetMetric("rateUnits", sensor.RateUnits, metric.ATTRIBUTE))
+			}
+			rollUp(elementHealthState(sensor.HealthState))
+		}
+	}
+
+	if info := host.Runtime.HealthSystemRuntime.HardwareStatusInfo; info != nil {
+		for _, element := range info.CpuStatusInfo {
+			hw := element.GetHostHardwareElementInfo()
+			newHostSensorMetricSet(config, e, host, datacenterName, hw.Name, sensorTypeCPU, hw.Status)
+			rollUp(elementHealthState(hw.Status))
+		}
+		for _, element := range info.MemoryStatusInfo {
+			hw := element.GetHostHardwareElementInfo()
+			newHostSensorMetricSet(config, e, host, datacenterName, hw.Name, sensorTypeMemory, hw.Status)
+			rollUp(elementHealthState(hw.Status))
+		}
+		for _, element := range info.StorageStatusInfo {
+			newHostSensorMetricSet(config, e, host, datacenterName, element.Name, sensorTypeStorage, element.Status)
+			rollUp(elementHealthState(element.Status))
+		}
+	}
+	return healthStatus
+}
+
+func newHostSensorMetricSet(config *config.Config, e *integration.Entity, host *mo.HostSystem, datacenterName string, name string, sensorType string, state types.BaseElementDescription) *metric.Set {
+	ms := newMetricSet(config, e, "VSphere"+sampleTypeHostSensor+"Sample")
+	if config.IsVcenterAPIType {
+		checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+	}
+	if config.Args.DatacenterLocation != "" {
+		checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("hypervisorHostname", host.Summary.Config.Name, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("sensorName", name, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("sensorType", sensorType, metric.ATTRIBUTE))
+	if healthState := elementHealthState(state); healthState != "" {
+		checkError(config.Logrus, ms.SetMetric("healthState", healthState, metric.ATTRIBUTE))
+	}
+	return ms
+}
+
+// elementHealthState returns the health state key in lowercase, es: green, empty if the element does not report it.
+// Numeric sensors report lowercase keys while hardware elements report capitalized ones, es: Green
+func elementHealthState(state types.BaseElementDescription) string {
+	if state == nil {
+		return ""
+	}
+	return strings.ToLower(state.GetElementDescription().Key)
+}
@@ -0,0 +1,90 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createHostSamples_HasSensorSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		dc := getDatacenter(ctx, vm)
+		cfg.Datacenters = append(cfg.Datacenters, dc)
+		collect.Hosts(cfg)
+
+		for _, host := range dc.Hosts {
+			host.Runtime.HealthSystemRuntime = &types.HealthSystemRuntime{
+				SystemHealthInfo: &types.HostSystemHealthInfo{
+					NumericSensorInfo: []types.HostNumericSensorInfo{{
+						Name:           "Fan 1",
+						HealthState:    &types.ElementDescription{Key: "green"},
+						CurrentReading: 540,
+						UnitModifier:   1,
+						BaseUnits:      "RPM",
+						SensorType:     "fan",
+					}},
+				},
+				HardwareStatusInfo: &types.HostHardwareStatusInfo{
+					CpuStatusInfo: []types.BaseHostHardwareElementInfo{
+						&types.HostHardwareElementInfo{Name: "CPU 1", Status: &types.ElementDescription{Key: "Green"}},
+					},
+					MemoryStatusInfo: []types.BaseHostHardwareElementInfo{
+						&types.HostHardwareElementInfo{Name: "DIMM 2", Status: &types.ElementDescription{Key: "Red"}},
+					},
+				},
+			}
+		}
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		var hostSample map[string]interface{}
+		sensorSamples := map[string]map[string]interface{}{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				switch ms.Metrics["event_type"] {
+				case "VSphereHostSample":
+					hostSample = ms.Metrics
+				case "VSphereHostSensorSample":
+					sensorSamples[ms.Metrics["sensorName"].(string)] = ms.Metrics
+				}
+			}
+		}
+		require.NotNil(t, hostSample)
+		assert.Equal(t, "red", hostSample["hardwareHealthStatus"], "a red hardware element raises the status of a green sensor")
+
+		require.Contains(t, sensorSamples, "Fan 1")
+		assert.Equal(t, "fan", sensorSamples["Fan 1"]["sensorType"])
+		assert.Equal(t, "green", sensorSamples["Fan 1"]["healthState"])
+		assert.Equal(t, float64(5400), sensorSamples["Fan 1"]["reading"])
+		assert.Equal(t, "RPM", sensorSamples["Fan 1"]["baseUnits"])
+		assert.Equal(t, hostSample["hypervisorHostname"], sensorSamples["Fan 1"]["hypervisorHostname"])
+
+		require.Contains(t, sensorSamples, "DIMM 2")
+		assert.Equal(t, "memory", sensorSamples["DIMM 2"]["sensorType"])
+		assert.Equal(t, "red", sensorSamples["DIMM 2"]["healthState"])
+
+		require.Contains(t, sensorSamples, "CPU 1")
+		assert.Equal(t, "green", sensorSamples["CPU 1"]["healthState"])
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// createHostStorageDeviceSamples adds a sample to the host entity for each SCSI LUN seen by the host, with its
+// multipathing policy and the state of its paths
+func createHostStorageDeviceSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem, datacenterName string) {
+	if host.Config == nil || host.Config.StorageDevice == nil {
+		return
+	}
+	storageDevice := host.Config.StorageDevice
+
+	hbaByKey := map[string]string{}
+	for _, hba := range storageDevice.HostBusAdapter {
+		adapter := hba.GetHostHostBusAdapter()
+		hbaByKey[adapter.Key] = adapter.Device
+	}
+	multipathByLun := map[string]*types.HostMultipathInfoLogicalUnit{}
+	if storageDevice.MultipathInfo != nil {
+		for i, lu := range storageDevice.MultipathInfo.Lun {
+			multipathByLun[lu.Lun] = &storageDevice.MultipathInfo.Lun[i]
+		}
+	}
+
+	for _, l := range storageDevice.ScsiLun {
+		lun := l.GetScsiLun()
+
+		ms := newMetricSet(config, e, "VSphere"+sampleTypeHostStorageDevice+"Sample")
+		if config.IsVcenterAPIType {
+			checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+		}
+		if config.Args.DatacenterLocation != "" {
+			checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+		}
+		checkError(config.Logrus, ms.SetMetric("hypervisorHostname", host.Summary.Config.Name, metric.ATTRIBUTE))
+
+		checkError(config.Logrus, ms.SetMetric("canonicalName", lun.CanonicalName, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("displayName", lun.DisplayName, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("lunType", lun.LunType, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("vendor", strings.TrimSpace(lun.Vendor), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("model", strings.TrimSpace(lun.Model), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("operationalState", strings.Join(lun.OperationalState, "|"), metric.ATTRIBUTE))
+		if disk, ok := l.(*types.HostScsiDisk); ok {
+			capacity := disk.Capacity.Block * int64(disk.Capacity.BlockSize)
+			checkError(config.Logrus, ms.SetMetric("capacity", float64(capacity)/(1<<30), metric.GAUGE))
+		}
+
+		lu, ok := multipathByLun[lun.Key]
+		if !ok {
+			continue
+		}
+		if lu.Policy != nil {
+			checkError(config.Logrus, ms.SetMetric("multipathPolicy", lu.Policy.GetHostMultipathInfoLogicalUnitPolicy().Policy, metric.ATTRIBUTE))
+		}
+		if lu.StorageArrayTypePolicy != nil {
+			checkError(config.Logrus, ms.SetMetric("storageArrayTypePolicy", lu.StorageArrayTypePolicy.Policy, metric.ATTRIBUTE))
+		}
+
+		pathsByState := map[string]int{}
+		var hbaList []string
+		seenHba := map[string]bool{}
+		for _, path := range lu.Path {
+			state := path.State
+			if state == "" {
+				state = path.PathState
+			}
+			pathsByState[state]++
+
+			if hba, ok := hbaByKey[path.Adapter]; ok && !seenHba[hba] {
+				seenHba[hba] = true
+				hbaList = append(hbaList, hba)
+			}
+		}
+		checkError(config.Logrus, ms.SetMetric("paths.total", len(lu.Path), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("paths.active", pathsByState[string(types.MultipathStateActive)], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("paths.standby", pathsByState[string(types.MultipathStateStandby)], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("paths.dead", pathsByState[string(types.MultipathStateDead)], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("paths.disabled", pathsByState[string(types.MultipathStateDisabled)], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("hba", strings.Join(hbaList, "|"), metric.ATTRIBUTE))
+	}
+}
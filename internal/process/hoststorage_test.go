@@ -0,0 +1,69 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createHostSamples_HasStorageDeviceSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		dc := getDatacenter(ctx, vm)
+		cfg.Datacenters = append(cfg.Datacenters, dc)
+		collect.Hosts(cfg)
+
+		for _, host := range dc.Hosts {
+			mp := host.Config.StorageDevice.MultipathInfo
+			for i := range mp.Lun {
+				if mp.Lun[i].Id == "0000000000766d686261303a303a30" {
+					// the disk has an additional dead path through the same adapter
+					path := mp.Lun[i].Path[0]
+					path.Name = "vmhba0:C0:T1:L0"
+					path.State = string(types.MultipathStateDead)
+					mp.Lun[i].Path = append(mp.Lun[i].Path, path)
+				}
+			}
+		}
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		var diskSample map[string]interface{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] == "VSphereHostStorageDeviceSample" && ms.Metrics["canonicalName"] == "mpx.vmhba0:C0:T0:L0" {
+					diskSample = ms.Metrics
+				}
+			}
+		}
+		require.NotNil(t, diskSample)
+		assert.Equal(t, "disk", diskSample["lunType"])
+		assert.Equal(t, "ok", diskSample["operationalState"])
+		assert.Equal(t, "VMW_PSP_FIXED", diskSample["multipathPolicy"])
+		assert.Equal(t, float64(2), diskSample["paths.total"])
+		assert.Equal(t, float64(1), diskSample["paths.active"])
+		assert.Equal(t, float64(1), diskSample["paths.dead"])
+		assert.Equal(t, "vmhba0", diskSample["hba"])
+		assert.Contains(t, diskSample, "capacity")
+		return nil
+	})
+}
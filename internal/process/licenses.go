@@ -0,0 +1,114 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	eventSDK "github.com/newrelic/infra-integrations-sdk/v3/data/event"
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+)
+
+const (
+	licenseEventCategory = "vSphereLicense"
+	licenseUsagePrefix   = "license_usage_"
+	licenseExpiryPrefix  = "license_expiry_"
+)
+
+// createLicenseSamples adds a sample to the vCenter entity for each license key, sending an event when the usage of
+// the license is above the usage threshold or its expiration reaches one of the expiry thresholds
+func createLicenseSamples(config *config.Config, e *integration.Entity) {
+	for _, license := range config.Licenses {
+		maskedKey := license.MaskedKey()
+		// license keys are not kept in the events store
+		storeID := licenseStoreID(license)
+
+		ms := newMetricSet(config, e, "VSphere"+sampleTypeLicense+"Sample")
+		if config.Args.DatacenterLocation != "" {
+			checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+		}
+		checkError(config.Logrus, ms.SetMetric("vcenterHostname", config.VMWareClient.URL().Hostname(), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("licenseKey", maskedKey, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("name", license.Info.Name, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("edition", license.Info.EditionKey, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("costUnit", license.Info.CostUnit, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("total", license.Info.Total, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("used", license.Info.Used, metric.GAUGE))
+
+		// when the assignments could not be queried the license would look assigned to nothing
+		if license.AssignmentsRetrieved {
+			assigned := make([]string, len(license.AssignedEntities))
+			copy(assigned, license.AssignedEntities)
+			sort.Strings(assigned)
+			checkError(config.Logrus, ms.SetMetric("assignedEntities", strings.Join(assigned, "|"), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("assignedEntityCount", len(assigned), metric.GAUGE))
+		}
+
+		if usage, ok := license.UsagePercentage(); ok {
+			checkError(config.Logrus, ms.SetMetric("usagePercentage", usage, metric.GAUGE))
+			threshold := config.Args.LicenseUsageThreshold
+			if threshold > 0 && usage > float64(threshold) {
+				if !thresholdNotified(config, licenseUsagePrefix+storeID, threshold) {
+					summary := fmt.Sprintf("License %s %s is %.0f%% used, %d of %d %s", license.Info.Name, maskedKey, usage, license.Info.Used, license.Info.Total, license.Info.CostUnit)
+					addLicenseEvent(config, e, license, summary, "license.usageThreshold", threshold)
+				}
+			} else if config.EventStore != nil {
+				// notified again once the usage goes back above the threshold
+				checkError(config.Logrus, config.EventStore.Delete(licenseUsagePrefix+storeID))
+			}
+		}
+
+		expiration, expires := license.Expiration()
+		checkError(config.Logrus, ms.SetMetric("expires", strconv.FormatBool(expires), metric.ATTRIBUTE))
+		if !expires {
+			continue
+		}
+		daysUntilExpiry := model.DaysUntil(expiration, time.Now())
+		checkError(config.Logrus, ms.SetMetric("expirationDate", expiration.UTC().Format(time.RFC3339), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("daysUntilExpiry", daysUntilExpiry, metric.GAUGE))
+
+		threshold, crossed := model.CrossedExpiryThreshold(config.LicenseThresholds, daysUntilExpiry)
+		// the expiration is part of the key, so a renewed license is notified again
+		expiryKey := licenseExpiryPrefix + storeID + "_" + strconv.FormatInt(expiration.Unix(), 10)
+		if crossed && !thresholdNotified(config, expiryKey, threshold) {
+			summary := fmt.Sprintf("License %s %s expires in %d days", license.Info.Name, maskedKey, daysUntilExpiry)
+			if daysUntilExpiry < 0 {
+				summary = fmt.Sprintf("License %s %s expired %d days ago", license.Info.Name, maskedKey, -daysUntilExpiry)
+			}
+			addLicenseEvent(config, e, license, summary, "license.expiryThreshold", threshold)
+		}
+	}
+}
+
+func addLicenseEvent(config *config.Config, e *integration.Entity, license *model.License, summary string, thresholdAttribute string, threshold int) {
+	ev := &eventSDK.Event{
+		Summary:  summary,
+		Category: licenseEventCategory,
+		Attributes: map[string]interface{}{
+			"license.key":      license.MaskedKey(),
+			"license.name":     license.Info.Name,
+			"license.edition":  license.Info.EditionKey,
+			"license.total":    license.Info.Total,
+			"license.used":     license.Info.Used,
+			"license.costUnit": license.Info.CostUnit,
+			thresholdAttribute: threshold,
+		},
+	}
+	checkError(config.Logrus, e.AddEvent(ev))
+}
+
+// licenseStoreID identifies a license in the events store without keeping its key
+func licenseStoreID(license *model.License) string {
+	sum := sha256.Sum256([]byte(license.Info.LicenseKey))
+	return hex.EncodeToString(sum[:])
+}
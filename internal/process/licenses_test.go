@@ -0,0 +1,95 @@
+package process
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createVcenterSamples_HasLicenseSamples(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		cfg := &config.Config{VMWareClient: vmClient, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Args.LicenseUsageThreshold = 90
+		cfg.LicenseThresholds = []int{7, 30}
+		cfg.EventStore = persist.NewInMemoryStore()
+		cfg.Licenses = []*model.License{
+			{
+				Info: types.LicenseManagerLicenseInfo{
+					LicenseKey: "AB123-CD456-EF789-GH012-4T2H1",
+					EditionKey: "esx.enterprisePlus.cpuPackage",
+					Name:       "VMware vSphere 8 Enterprise Plus",
+					Total:      16,
+					Used:       15,
+					CostUnit:   "cpuPackage",
+					Properties: []types.KeyAnyValue{
+						{Key: "expirationDate", Value: time.Now().Add(10*24*time.Hour + time.Hour)},
+					},
+				},
+				AssignedEntities:     []string{"esx02", "esx01"},
+				AssignmentsRetrieved: true,
+			},
+			{
+				Info: types.LicenseManagerLicenseInfo{
+					LicenseKey: "00000-00000-00000-00000-00000",
+					EditionKey: "eval",
+					Name:       "Evaluation Mode",
+				},
+			},
+		}
+
+		// when executed twice events are not sent again
+		var e *integration.Entity
+		for i := 0; i < 2; i++ {
+			cfg.Integration, _ = integration.New("test", "dev")
+			createVcenterSamples(cfg)
+			require.Len(t, cfg.Integration.Entities, 1)
+			e = cfg.Integration.Entities[0]
+			if i == 0 {
+				require.Len(t, e.Events, 2)
+			}
+		}
+
+		// then
+		assert.Empty(t, e.Events)
+		var licenseSamples []map[string]interface{}
+		for _, ms := range e.Metrics {
+			if ms.Metrics["event_type"] == "VSphereLicenseSample" {
+				licenseSamples = append(licenseSamples, ms.Metrics)
+			}
+		}
+		require.Len(t, licenseSamples, 2)
+		sample := licenseSamples[0]
+		assert.Equal(t, "XXXXX-XXXXX-XXXXX-XXXXX-4T2H1", sample["licenseKey"])
+		assert.Equal(t, "esx.enterprisePlus.cpuPackage", sample["edition"])
+		assert.Equal(t, "cpuPackage", sample["costUnit"])
+		assert.Equal(t, float64(16), sample["total"])
+		assert.Equal(t, float64(15), sample["used"])
+		assert.Equal(t, float64(93.75), sample["usagePercentage"])
+		assert.Equal(t, "esx01|esx02", sample["assignedEntities"])
+		assert.Equal(t, "true", sample["expires"])
+		assert.Equal(t, float64(10), sample["daysUntilExpiry"])
+
+		eval := licenseSamples[1]
+		assert.Equal(t, "XXXXX-XXXXX-XXXXX-XXXXX-00000", eval["licenseKey"])
+		assert.Equal(t, "false", eval["expires"])
+		assert.NotContains(t, eval, "usagePercentage")
+		assert.NotContains(t, eval, "assignedEntities", "assignments not retrieved are not reported as empty")
+		assert.NotContains(t, eval, "assignedEntityCount")
+	})
+}
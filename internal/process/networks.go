@@ -0,0 +1,49 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/nri-vsphere/internal/config"
+)
+
+// createNetworkSamples reports the performance metrics of networks and distributed port groups.
+// Networks do not have any other metric, so samples are created only for the ones having perf metrics.
+func createNetworkSamples(config *config.Config) {
+	if !config.PerfMetricsCollectionEnabled() {
+		return
+	}
+
+	for _, dc := range config.Datacenters {
+		for _, network := range dc.Networks {
+			perfMetrics := dc.GetPerfMetrics(network.Self)
+			if len(perfMetrics) == 0 {
+				continue
+			}
+
+			datacenterName := dc.Datacenter.Name
+			entityName := sanitizeEntityName(config, network.Name, datacenterName)
+
+			e, ms, err := createNewEntityWithMetricSet(config, entityTypeNetwork, entityName, entityName)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("networkName", entityName).Error("failed to create metricSet")
+				continue
+			}
+
+			checkError(config.Logrus, ms.SetMetric("networkName", network.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("networkType", network.Self.Type, metric.ATTRIBUTE))
+
+			if config.Args.DatacenterLocation != "" {
+				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+			}
+
+			if config.IsVcenterAPIType {
+				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+			}
+
+			addCustomAttributes(config, e, ms, &network.ManagedEntity)
+			addPerfMetrics(config, e, ms, entityTypeNetwork, perfMetrics)
+		}
+	}
+}
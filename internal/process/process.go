@@ -4,37 +4,67 @@
 package process
 
 import (
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
 	"github.com/newrelic/infra-integrations-sdk/v3/integration"
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+	"github.com/newrelic/nri-vsphere/internal/tag"
+	"github.com/vmware/govmomi/vim25/mo"
 
 	logrus "github.com/sirupsen/logrus"
 )
 
 const (
-	entityTypeDatacenter   = "Datacenter"
-	entityTypeCluster      = "Cluster"
-	entityTypeHost         = "Host"
-	entityTypeResourcePool = "ResourcePool"
-	entityTypeVm           = "Vm"
-	entityTypeDatastore    = "Datastore"
+	entityTypeDatacenter       = "Datacenter"
+	entityTypeCluster          = "Cluster"
+	entityTypeHost             = "Host"
+	entityTypeResourcePool     = "ResourcePool"
+	entityTypeVm               = "Vm"
+	entityTypeDatastore        = "Datastore"
+	entityTypeDatastoreCluster = "DatastoreCluster"
+	entityTypeNetwork          = "Network"
+	entityTypeVcenter          = "Vcenter"
 	//The sampleTypeSnapshotVm is used to create a sample, however it does not have a corresponding entity
 	//sampleTypeSnapshotVm is attached to a vm entity.
 	sampleTypeSnapshotVm = "SnapshotVm"
+	//sampleTypeVsanCluster is attached to a cluster entity with vSAN enabled.
+	sampleTypeVsanCluster = "VsanCluster"
+	//sampleTypeHostSensor is attached to a host entity, one for each hardware sensor.
+	sampleTypeHostSensor = "HostSensor"
+	//sampleTypeHostStorageDevice is attached to a host entity, one for each SCSI LUN.
+	sampleTypeHostStorageDevice = "HostStorageDevice"
+	//sampleTypeHostNic is attached to a host entity, one for each physical nic and vmkernel adapter.
+	sampleTypeHostNic = "HostNic"
+	//sampleTypeHostSecurity is attached to a host entity.
+	sampleTypeHostSecurity = "HostSecurity"
+	//sampleTypeAppliance is attached to a vcenter entity.
+	sampleTypeAppliance = "Appliance"
+	//sampleTypeLicense is attached to a vcenter entity, one for each license key.
+	sampleTypeLicense = "License"
 
 	tagsPrefix       = "label."
 	tagsInventoryKey = "tags"
 	perfMetricPrefix = "perf."
+	// inherited tags are reported with both prefixes so they can be told apart from the ones attached to the object
+	inheritedTagsPrefix = "inheritedLabel."
+
+	// maxPerfMetricsPerSample limits the number of perf metrics added to a single sample to avoid reaching the 255
+	// attributes limit per event. Any perf metric above the limit is sent in additional VSphere<type>PerfSample pages.
+	maxPerfMetricsPerSample = 150
 )
 
 // Run process samples
 func ProcessData(config *config.Config) {
 	// create samples async
 	var wg sync.WaitGroup
-	wg.Add(6)
+	wg.Add(9)
 	go func() {
 		defer wg.Done()
 		createVirtualMachineSamples(config)
@@ -47,6 +77,10 @@ func ProcessData(config *config.Config) {
 		defer wg.Done()
 		createDatastoreSamples(config)
 	}()
+	go func() {
+		defer wg.Done()
+		createDatastoreClusterSamples(config)
+	}()
 	go func() {
 		defer wg.Done()
 		createDatacenterSamples(config)
@@ -59,6 +93,14 @@ func ProcessData(config *config.Config) {
 		defer wg.Done()
 		createResourcePoolSamples(config)
 	}()
+	go func() {
+		defer wg.Done()
+		createNetworkSamples(config)
+	}()
+	go func() {
+		defer wg.Done()
+		createVcenterSamples(config)
+	}()
 	wg.Wait()
 }
 
@@ -101,6 +143,22 @@ func determineOS(guestFullName string) string {
 	return "unknown"
 }
 
+// thresholdNotified returns true if an event was already sent for the threshold, or a lower one, of the given key and
+// records it otherwise
+func thresholdNotified(config *config.Config, key string, threshold int) bool {
+	if config.EventStore == nil {
+		return false
+	}
+	var notified int
+	if _, err := config.EventStore.Get(key, &notified); err == nil && notified <= threshold {
+		// refresh the entry so it does not expire while it is reported
+		config.EventStore.Set(key, notified)
+		return true
+	}
+	config.EventStore.Set(key, threshold)
+	return false
+}
+
 func checkError(logger *logrus.Logger, err error) {
 	if err != nil {
 		logger.WithError(err).Error("failed to set")
@@ -132,12 +190,97 @@ func createNewEntityWithMetricSet(config *config.Config, typeEntity string, enti
 	if config.Args.HasInventory() {
 		checkError(config.Logrus, workingEntity.SetInventoryItem("vsphere"+typeEntity, "name", entityName))
 	}
-	ms := workingEntity.NewMetricSet("VSphere" + typeEntity + "Sample")
+	ms := newMetricSet(config, workingEntity, "VSphere"+typeEntity+"Sample")
 	return workingEntity, ms, nil
 }
 
+// newMetricSet adds a sample to the entity having the instance uuid of the vCenter, that distinguishes the entities
+// of several vCenters
+func newMetricSet(config *config.Config, e *integration.Entity, eventType string) *metric.Set {
+	ms := e.NewMetricSet(eventType)
+	if config.IsVcenterAPIType && config.VMWareClient != nil {
+		checkError(config.Logrus, ms.SetMetric("vcenterInstanceUuid", config.VMWareClient.ServiceContent.About.InstanceUuid, metric.ATTRIBUTE))
+	}
+	return ms
+}
+
 func addTagsToInventory(config *config.Config, e *integration.Entity, category, tag string) {
 	if config.Args.HasInventory() {
 		checkError(config.Logrus, e.SetInventoryItem(tagsInventoryKey, tagsPrefix+category, tag))
 	}
 }
+
+// addCustomAttributes adds the custom attributes of the entity to its sample and, due to the inventory workaround,
+// to the inventory next to the tags
+func addCustomAttributes(config *config.Config, e *integration.Entity, ms *metric.Set, entity *mo.ManagedEntity) {
+	if !config.CustomAttributesEnabled() {
+		return
+	}
+	for name, value := range model.CustomAttributes(entity) {
+		checkError(config.Logrus, ms.SetMetric(tag.CustomAttributePrefix+name, value, metric.ATTRIBUTE))
+		if config.Args.HasInventory() {
+			checkError(config.Logrus, e.SetInventoryItem(tagsInventoryKey, tag.CustomAttributePrefix+name, value))
+		}
+	}
+}
+
+// addPerfMetrics adds the perf metrics of an entity to its sample together with the timestamp of the most recent sample.
+// When every sample is collected, each one is reported in its own VSphere<type>PerfSample having the sample timestamp.
+func addPerfMetrics(config *config.Config, e *integration.Entity, ms *metric.Set, typeEntity string, perfMetrics []performance.PerfMetric) {
+	if config.Args.PerfSampleMode != performance.SampleModeAll {
+		var lastSample time.Time
+		for _, perfMetric := range perfMetrics {
+			if perfMetric.Timestamp.After(lastSample) {
+				lastSample = perfMetric.Timestamp
+			}
+		}
+		if !lastSample.IsZero() {
+			checkError(config.Logrus, ms.SetMetric("perfSampleTimestamp", lastSample.Unix(), metric.GAUGE))
+		}
+		addPerfMetricPages(config, e, ms, typeEntity, perfMetrics, time.Time{})
+		return
+	}
+
+	perfMetricsBySample := map[time.Time][]performance.PerfMetric{}
+	var samples []time.Time
+	for _, perfMetric := range perfMetrics {
+		if _, ok := perfMetricsBySample[perfMetric.Timestamp]; !ok {
+			samples = append(samples, perfMetric.Timestamp)
+		}
+		perfMetricsBySample[perfMetric.Timestamp] = append(perfMetricsBySample[perfMetric.Timestamp], perfMetric)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Before(samples[j])
+	})
+
+	for _, sample := range samples {
+		sampleMs := newMetricSet(config, e, "VSphere"+typeEntity+"PerfSample")
+		checkError(config.Logrus, sampleMs.SetMetric("timestamp", sample.Unix(), metric.GAUGE))
+		addPerfMetricPages(config, e, sampleMs, typeEntity, perfMetricsBySample[sample], sample)
+	}
+}
+
+// addPerfMetricPages adds the perf metrics to the given sample. If there are more than maxPerfMetricsPerSample
+// counters the remaining ones are split in pages, each one a VSphere<type>PerfSample attached to the same entity.
+// Counters are sorted by name so each one is always reported in the same sample.
+func addPerfMetricPages(config *config.Config, e *integration.Entity, ms *metric.Set, typeEntity string, perfMetrics []performance.PerfMetric, timestamp time.Time) {
+	sorted := make([]performance.PerfMetric, len(perfMetrics))
+	copy(sorted, perfMetrics)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Counter < sorted[j].Counter
+	})
+
+	for i, page := 0, 0; i < len(sorted); i, page = i+maxPerfMetricsPerSample, page+1 {
+		pageMs := ms
+		if page > 0 {
+			pageMs = newMetricSet(config, e, "VSphere"+typeEntity+"PerfSample")
+			checkError(config.Logrus, pageMs.SetMetric("perfSamplePage", strconv.Itoa(page), metric.ATTRIBUTE))
+			if !timestamp.IsZero() {
+				checkError(config.Logrus, pageMs.SetMetric("timestamp", timestamp.Unix(), metric.GAUGE))
+			}
+		}
+		for _, perfMetric := range sorted[i:min(i+maxPerfMetricsPerSample, len(sorted))] {
+			checkError(config.Logrus, pageMs.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package process
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_addPerfMetrics_SplitsCountersInPages(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger()}
+	cfg.Integration, _ = integration.New("test", "dev")
+
+	e, ms, err := createNewEntityWithMetricSet(cfg, entityTypeVm, "vm", "vm")
+	require.NoError(t, err)
+
+	var perfMetrics []performance.PerfMetric
+	for i := 0; i < 2*maxPerfMetricsPerSample+10; i++ {
+		perfMetrics = append(perfMetrics, performance.PerfMetric{Counter: fmt.Sprintf("counter.%03d", i), Value: int64(i)})
+	}
+	// counters are sorted by name regardless of the order they are returned
+	perfMetrics[0], perfMetrics[len(perfMetrics)-1] = perfMetrics[len(perfMetrics)-1], perfMetrics[0]
+
+	addPerfMetrics(cfg, e, ms, entityTypeVm, perfMetrics)
+
+	require.Len(t, e.Metrics, 3)
+	assert.Equal(t, "VSphereVmSample", e.Metrics[0].Metrics["event_type"])
+	assert.Contains(t, e.Metrics[0].Metrics, "perf.counter.000")
+	assert.NotContains(t, e.Metrics[0].Metrics, fmt.Sprintf("perf.counter.%03d", maxPerfMetricsPerSample))
+
+	assert.Equal(t, "VSphereVmPerfSample", e.Metrics[1].Metrics["event_type"])
+	assert.Equal(t, "1", e.Metrics[1].Metrics["perfSamplePage"])
+	assert.Contains(t, e.Metrics[1].Metrics, fmt.Sprintf("perf.counter.%03d", maxPerfMetricsPerSample))
+
+	assert.Equal(t, "2", e.Metrics[2].Metrics["perfSamplePage"])
+	// event_type, perfSamplePage and the remaining 10 counters
+	assert.Len(t, e.Metrics[2].Metrics, 12)
+}
+
+func Test_addPerfMetrics_AllSamplesHaveTheirOwnTimestamp(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger()}
+	cfg.Args.PerfSampleMode = performance.SampleModeAll
+	cfg.Integration, _ = integration.New("test", "dev")
+
+	e, ms, err := createNewEntityWithMetricSet(cfg, entityTypeHost, "host", "host")
+	require.NoError(t, err)
+
+	first := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(20 * time.Second)
+	addPerfMetrics(cfg, e, ms, entityTypeHost, []performance.PerfMetric{
+		{Counter: "cpu.usage.average", Value: 2, Timestamp: second},
+		{Counter: "cpu.usage.average", Value: 1, Timestamp: first},
+	})
+
+	require.Len(t, e.Metrics, 3)
+	assert.NotContains(t, e.Metrics[0].Metrics, "perf.cpu.usage.average")
+	assert.Equal(t, "VSphereHostPerfSample", e.Metrics[1].Metrics["event_type"])
+	assert.Equal(t, float64(first.Unix()), e.Metrics[1].Metrics["timestamp"])
+	assert.Equal(t, float64(1), e.Metrics[1].Metrics["perf.cpu.usage.average"])
+	assert.Equal(t, float64(second.Unix()), e.Metrics[2].Metrics["timestamp"])
+	assert.Equal(t, float64(2), e.Metrics[2].Metrics["perf.cpu.usage.average"])
+}
+
+func Test_createNetworkSamples_OnlyNetworksWithPerfMetrics(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+	cfg.Args.EnableVspherePerfMetrics = true
+	cfg.Integration, _ = integration.New("test", "dev")
+
+	portgroup := types.ManagedObjectReference{Type: "DistributedVirtualPortgroup", Value: "dvportgroup-1"}
+	network := types.ManagedObjectReference{Type: "Network", Value: "network-1"}
+
+	dc := model.NewDatacenter(&mo.Datacenter{ManagedEntity: mo.ManagedEntity{Name: "DC0"}})
+	dc.Networks[portgroup] = &mo.Network{ManagedEntity: mo.ManagedEntity{ExtensibleManagedObject: mo.ExtensibleManagedObject{Self: portgroup}}, Name: "DC0_DVPG0"}
+	dc.Networks[network] = &mo.Network{ManagedEntity: mo.ManagedEntity{ExtensibleManagedObject: mo.ExtensibleManagedObject{Self: network}}, Name: "VM Network"}
+	dc.AddPerfMetrics(map[types.ManagedObjectReference][]performance.PerfMetric{
+		portgroup: {{Counter: "net.droppedRx.summation", Value: 3}},
+	})
+	cfg.Datacenters = append(cfg.Datacenters, dc)
+
+	createNetworkSamples(cfg)
+
+	require.Len(t, cfg.Integration.Entities, 1)
+	e := cfg.Integration.Entities[0]
+	assert.Equal(t, "vsphere-network", e.Metadata.Namespace)
+	assert.Equal(t, "dc0:dc0_dvpg0", e.Metadata.Name)
+	require.Len(t, e.Metrics, 1)
+	assert.Equal(t, "VSphereNetworkSample", e.Metrics[0].Metrics["event_type"])
+	assert.Equal(t, "DistributedVirtualPortgroup", e.Metrics[0].Metrics["networkType"])
+	assert.Equal(t, float64(3), e.Metrics[0].Metrics["perf.net.droppedRx.summation"])
+}
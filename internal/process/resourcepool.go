@@ -4,6 +4,8 @@
 package process
 
 import (
+	"strings"
+
 	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
 	"github.com/newrelic/nri-vsphere/internal/config"
 )
@@ -32,7 +34,10 @@ func createResourcePoolSamples(config *config.Config) {
 			} else if host := dc.FindHost(rp.Owner); host != nil {
 				ownerName = host.Summary.Config.Name
 			}
-			entityName := ownerName + ":" + resourcePoolName
+			// nested pools are named after their whole path so that pools with the same name under
+			// different parents do not collide, top level pools keep the owner:name format
+			poolPath := dc.ResourcePoolPath(rp.Self)
+			entityName := ownerName + ":" + strings.Join(poolPath, "/")
 			entityName = sanitizeEntityName(config, entityName, datacenterName)
 
 			e, ms, err := createNewEntityWithMetricSet(config, entityTypeResourcePool, entityName, entityName)
@@ -42,6 +47,12 @@ func createResourcePoolSamples(config *config.Config) {
 			}
 
 			checkError(config.Logrus, ms.SetMetric("resourcePoolName", resourcePoolName, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("resourcePoolPath", strings.Join(append([]string{ownerName}, poolPath...), "/"), metric.ATTRIBUTE))
+			if rp.Parent != nil {
+				if parent, ok := dc.GetResourcePool(*rp.Parent); ok {
+					checkError(config.Logrus, ms.SetMetric("parentPool", parent.Name, metric.ATTRIBUTE))
+				}
+			}
 
 			if config.Args.DatacenterLocation != "" {
 				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
@@ -72,8 +83,25 @@ func createResourcePoolSamples(config *config.Config) {
 
 			checkError(config.Logrus, ms.SetMetric("vmCount", len(rp.Vm), metric.GAUGE))
 
+			// Configuration, cpu in MHz and memory in MB
+			addResourceAllocation(config, ms, "cpu", "MHz", &rp.Config.CpuAllocation)
+			addResourceAllocation(config, ms, "mem", "MB", &rp.Config.MemoryAllocation)
+
+			// Runtime usage, esxi reports memory in bytes
+			checkError(config.Logrus, ms.SetMetric("runtime.overallStatus", string(rp.Runtime.OverallStatus), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("runtime.cpu.reservationUsedMHz", rp.Runtime.Cpu.ReservationUsed, metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.cpu.unreservedForPoolMHz", rp.Runtime.Cpu.UnreservedForPool, metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.cpu.maxUsageMHz", rp.Runtime.Cpu.MaxUsage, metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.cpu.overallUsageMHz", rp.Runtime.Cpu.OverallUsage, metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.mem.reservationUsedMB", rp.Runtime.Memory.ReservationUsed/(1<<20), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.mem.unreservedForPoolMB", rp.Runtime.Memory.UnreservedForPool/(1<<20), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.mem.maxUsageMB", rp.Runtime.Memory.MaxUsage/(1<<20), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("runtime.mem.overallUsageMB", rp.Runtime.Memory.OverallUsage/(1<<20), metric.GAUGE))
+
 			checkError(config.Logrus, ms.SetMetric("overallStatus", string(rp.OverallStatus), metric.ATTRIBUTE))
 
+			addCustomAttributes(config, e, ms, &rp.ManagedEntity)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(rp.Self)
@@ -85,10 +113,7 @@ func createResourcePoolSamples(config *config.Config) {
 			}
 			// Performance metrics
 			if config.PerfMetricsCollectionEnabled() {
-				perfMetrics := dc.GetPerfMetrics(rp.Self)
-				for _, perfMetric := range perfMetrics {
-					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
-				}
+				addPerfMetrics(config, e, ms, entityTypeResourcePool, dc.GetPerfMetrics(rp.Self))
 			}
 		}
 	}
@@ -0,0 +1,82 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createResourcePoolSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		finder := find.NewFinder(vc)
+		dc, err := finder.Datacenter(ctx, "DC0")
+		require.NoError(t, err)
+		finder.SetDatacenter(dc)
+		root, err := finder.ResourcePool(ctx, "DC0_C0/Resources")
+		require.NoError(t, err)
+
+		limit := int64(2048)
+		spec := types.DefaultResourceConfigSpec()
+		spec.MemoryAllocation.Limit = &limit
+		spec.CpuAllocation.Shares = &types.SharesInfo{Level: types.SharesLevelHigh}
+		prod, err := root.Create(ctx, "Prod", spec)
+		require.NoError(t, err)
+		_, err = prod.Create(ctx, "Web", types.DefaultResourceConfigSpec())
+		require.NoError(t, err)
+		_, err = root.Create(ctx, "Web", types.DefaultResourceConfigSpec())
+		require.NoError(t, err)
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+		collect.Clusters(cfg)
+		collect.ResourcePools(cfg)
+
+		// when
+		createResourcePoolSamples(cfg)
+
+		// then
+		samples := map[string]map[string]interface{}{}
+		for _, e := range cfg.Integration.Entities {
+			samples[e.Metadata.Name] = e.Metrics[0].Metrics
+		}
+		require.Contains(t, samples, "dc0:dc0_c0:prod")
+		require.Contains(t, samples, "dc0:dc0_c0:prod/web")
+		require.Contains(t, samples, "dc0:dc0_c0:web")
+
+		web := samples["dc0:dc0_c0:prod/web"]
+		assert.Equal(t, "Web", web["resourcePoolName"])
+		assert.Equal(t, "DC0_C0/Prod/Web", web["resourcePoolPath"])
+		assert.Equal(t, "Prod", web["parentPool"])
+
+		topWeb := samples["dc0:dc0_c0:web"]
+		assert.Equal(t, "DC0_C0/Web", topWeb["resourcePoolPath"])
+		assert.NotContains(t, topWeb, "parentPool")
+
+		prodSample := samples["dc0:dc0_c0:prod"]
+		assert.Equal(t, float64(2048), prodSample["mem.limitMB"])
+		assert.Equal(t, float64(-1), prodSample["cpu.limitMHz"])
+		assert.Equal(t, "high", prodSample["cpu.sharesLevel"])
+		assert.Equal(t, "true", prodSample["mem.expandableReservation"])
+		assert.Contains(t, prodSample, "runtime.overallStatus")
+		assert.Contains(t, prodSample, "runtime.mem.maxUsageMB")
+		return nil
+	})
+}
@@ -21,6 +21,8 @@ type snapshotProcessor struct {
 	vmLayoutEx      *types.VirtualMachineFileLayoutEx
 	currentSnapshot *types.ManagedObjectReference
 	logger          *logrus.Logger
+	// instance uuid of the vCenter added to the samples, empty when connected to a host
+	vcenterInstanceUUID string
 
 	// These structures are needed just to speedUpComputation.
 	filesInfoByKey     map[int32]types.VirtualMachineFileLayoutExFileInfo
@@ -246,6 +248,9 @@ func removeKey(l *[]int32, key int32) {
 func (sp snapshotProcessor) createSnapshotSamples(e *integration.Entity, treeInfo string, snapshotTrees []types.VirtualMachineSnapshotTree) {
 	for _, st := range snapshotTrees {
 		ms := e.NewMetricSet("VSphere" + sampleTypeSnapshotVm + "Sample")
+		if sp.vcenterInstanceUUID != "" {
+			checkError(sp.logger, ms.SetMetric("vcenterInstanceUuid", sp.vcenterInstanceUUID, metric.ATTRIBUTE))
+		}
 		treeInfo = treeInfo + ":" + st.Name
 
 		sp.createMetricsCurrentSnapshot(treeInfo, st, ms)
@@ -0,0 +1,99 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strconv"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// createVcenterSamples creates the entity of the vCenter the integration is connected to, with its version, the
+// inventory counts across datacenters, the certificate it presents and the metadata of the integration execution
+func createVcenterSamples(config *config.Config) {
+	if !config.IsVcenterAPIType {
+		return
+	}
+
+	about := config.VMWareClient.ServiceContent.About
+	vcenterHostname := config.VMWareClient.URL().Hostname()
+	entityName := sanitizeEntityName(config, vcenterHostname, "")
+	e, ms, err := createNewEntityWithMetricSet(config, entityTypeVcenter, entityName, entityName)
+	if err != nil {
+		config.Logrus.WithError(err).WithField("vcenterName", entityName).Error("failed to create metricSet")
+		return
+	}
+
+	if config.Args.DatacenterLocation != "" {
+		checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("vcenterHostname", vcenterHostname, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("product.name", about.Name, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("product.fullName", about.FullName, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("product.version", about.Version, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("product.build", about.Build, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("product.apiVersion", about.ApiVersion, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("instanceUuid", about.InstanceUuid, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("osType", about.OsType, metric.ATTRIBUTE))
+
+	// inventory counts across datacenters, when tag filtering is enabled only the objects matching the filters are counted
+	var clusters, hosts, vms, datastores, datastoreClusters, networks, resourcePools int
+	for _, dc := range config.Datacenters {
+		clusters += countMatching(config, dc.Clusters)
+		hosts += countMatching(config, dc.Hosts)
+		vms += countMatching(config, dc.VirtualMachines)
+		datastores += countMatching(config, dc.Datastores)
+		datastoreClusters += countMatching(config, dc.DatastoreClusters)
+		networks += countMatching(config, dc.Networks)
+		for _, resourcePool := range dc.ResourcePools {
+			if !dc.IsDefaultResourcePool(resourcePool.Reference()) && matchesTagFilters(config, resourcePool.Self) {
+				resourcePools++
+			}
+		}
+	}
+	checkError(config.Logrus, ms.SetMetric("inventory.tagFiltered", strconv.FormatBool(config.TagFilteringEnabled()), metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("inventory.datacenters", len(config.Datacenters), metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.clusters", clusters, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.hosts", hosts, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.vms", vms, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.datastores", datastores, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.datastoreClusters", datastoreClusters, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.networks", networks, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("inventory.resourcePools", resourcePools, metric.GAUGE))
+
+	// integration execution metadata, phases are the ones of the data collection
+	for phase, duration := range config.RunStats.Phases() {
+		checkError(config.Logrus, ms.SetMetric("collectionDuration."+phase, duration.Seconds(), metric.GAUGE))
+	}
+	apiCalls, apiErrors := config.RunStats.APICalls()
+	checkError(config.Logrus, ms.SetMetric("apiCalls", apiCalls, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("apiErrors", apiErrors, metric.GAUGE))
+
+	if config.VcenterCertificate != nil {
+		addCertificate(config, e, ms, config.VcenterCertificate, vcenterHostname)
+	}
+
+	if config.ApplianceHealth != nil {
+		createApplianceSample(config, e)
+	}
+
+	createLicenseSamples(config, e)
+}
+
+// countMatching returns the number of objects matching the tag filters, all of them if filtering is disabled
+func countMatching[T any](config *config.Config, objects map[types.ManagedObjectReference]T) int {
+	count := 0
+	for ref := range objects {
+		if matchesTagFilters(config, ref) {
+			count++
+		}
+	}
+	return count
+}
+
+func matchesTagFilters(config *config.Config, ref types.ManagedObjectReference) bool {
+	return !config.TagFilteringEnabled() || config.TagCollector.MatchObjectTags(ref)
+}
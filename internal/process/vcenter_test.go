@@ -0,0 +1,64 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_createVcenterSamples(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: view.NewManager(vmClient.Client), Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.RunStats = model.NewRunStats()
+		client.CountAPICalls(vmClient, cfg.RunStats)
+		require.NoError(t, collect.CollectData(cfg))
+
+		// when
+		createVcenterSamples(cfg)
+		createHostSamples(cfg)
+
+		// then
+		about := vmClient.ServiceContent.About
+		var vcenterSample map[string]interface{}
+		for _, e := range cfg.Integration.Entities {
+			// every sample has the instance uuid, including the ones attached to host entities
+			for _, ms := range e.Metrics {
+				assert.Equal(t, about.InstanceUuid, ms.Metrics["vcenterInstanceUuid"], ms.Metrics["event_type"])
+			}
+			if e.Metrics[0].Metrics["event_type"] == "VSphereVcenterSample" {
+				vcenterSample = e.Metrics[0].Metrics
+			}
+		}
+		require.NotNil(t, vcenterSample)
+		assert.Equal(t, about.Version, vcenterSample["product.version"])
+		assert.Equal(t, about.Build, vcenterSample["product.build"])
+		assert.Equal(t, about.InstanceUuid, vcenterSample["instanceUuid"])
+		assert.Equal(t, about.OsType, vcenterSample["osType"])
+		// default vcsim inventory: 1 datacenter, 1 cluster, 4 hosts and 4 vms
+		assert.Equal(t, float64(1), vcenterSample["inventory.datacenters"])
+		assert.Equal(t, float64(1), vcenterSample["inventory.clusters"])
+		assert.Equal(t, float64(4), vcenterSample["inventory.hosts"])
+		assert.Equal(t, float64(4), vcenterSample["inventory.vms"])
+		assert.Equal(t, "false", vcenterSample["inventory.tagFiltered"])
+		assert.Contains(t, vcenterSample, "collectionDuration.hosts")
+		assert.Contains(t, vcenterSample, "collectionDuration.vms")
+		assert.Greater(t, vcenterSample["apiCalls"], float64(0))
+		assert.Equal(t, float64(0), vcenterSample["apiErrors"])
+	})
+}
@@ -10,10 +10,14 @@ import (
 
 	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func createVirtualMachineSamples(config *config.Config) {
 	for _, dc := range config.Datacenters {
+		// placement settings by cluster, computed the first time a vm of the cluster is found
+		placements := map[types.ManagedObjectReference]map[types.ManagedObjectReference]*model.VMPlacement{}
 		for _, vm := range dc.VirtualMachines {
 
 			// filtering here will to avoid sending data to backend
@@ -83,6 +87,13 @@ func createVirtualMachineSamples(config *config.Config) {
 
 			if c, ok := dc.Clusters[vmHostParent]; ok {
 				checkError(config.Logrus, ms.SetMetric("clusterName", c.Name, metric.ATTRIBUTE))
+
+				if _, ok := placements[vmHostParent]; !ok {
+					placements[vmHostParent] = model.ClusterVMPlacements(c)
+				}
+				if p, ok := placements[vmHostParent][vm.Self]; ok {
+					addVMPlacement(config, ms, p)
+				}
 			}
 
 			checkError(config.Logrus, ms.SetMetric("hypervisorHostname", hostConfigName, metric.ATTRIBUTE))
@@ -150,6 +161,25 @@ func createVirtualMachineSamples(config *config.Config) {
 			}
 			checkError(config.Logrus, ms.SetMetric("cpu.allocationLimit", cpuAllocationLimit, metric.GAUGE))
 
+			// allocation, cpu in MHz and memory in MB
+			addResourceAllocation(config, ms, "cpu", "MHz", vm.Config.CpuAllocation)
+			addResourceAllocation(config, ms, "mem", "MB", vm.Config.MemoryAllocation)
+			if vm.Config.CpuHotAddEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("cpu.hotAddEnabled", strconv.FormatBool(*vm.Config.CpuHotAddEnabled), metric.ATTRIBUTE))
+			}
+			if vm.Config.CpuHotRemoveEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("cpu.hotRemoveEnabled", strconv.FormatBool(*vm.Config.CpuHotRemoveEnabled), metric.ATTRIBUTE))
+			}
+			if vm.Config.MemoryHotAddEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("mem.hotAddEnabled", strconv.FormatBool(*vm.Config.MemoryHotAddEnabled), metric.ATTRIBUTE))
+			}
+			if vm.Config.HotPlugMemoryLimit != 0 {
+				checkError(config.Logrus, ms.SetMetric("mem.hotPlugLimitMB", vm.Config.HotPlugMemoryLimit, metric.GAUGE))
+			}
+			if vm.Config.LatencySensitivity != nil {
+				checkError(config.Logrus, ms.SetMetric("latencySensitivity", string(vm.Config.LatencySensitivity.Level), metric.ATTRIBUTE))
+			}
+
 			if vmHost.Summary.Hardware != nil {
 				CPUMhz := vmHost.Summary.Hardware.CpuMhz
 				CPUCores := vmHost.Summary.Hardware.NumCpuCores
@@ -199,6 +229,8 @@ func createVirtualMachineSamples(config *config.Config) {
 			checkError(config.Logrus, ms.SetMetric("connectionState", fmt.Sprintf("%v", vm.Runtime.ConnectionState), metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("powerState", fmt.Sprintf("%v", vm.Runtime.PowerState), metric.ATTRIBUTE))
 
+			addCustomAttributes(config, e, ms, &vm.ManagedEntity)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(vm.Self)
@@ -207,19 +239,26 @@ func createVirtualMachineSamples(config *config.Config) {
 					// add tags to inventory due to the inventory workaround
 					addTagsToInventory(config, e, k, v)
 				}
+				for k, v := range config.TagCollector.GetInheritedTagsByCategories(vm.Self) {
+					checkError(config.Logrus, ms.SetMetric(inheritedTagsPrefix+k, v, metric.ATTRIBUTE))
+				}
 			}
 
 			// Performance metrics
 			if config.PerfMetricsCollectionEnabled() {
-				perfMetrics := dc.GetPerfMetrics(vm.Self)
-				for _, perfMetric := range perfMetrics {
-					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
+				if reason := model.VMPerfSkippedReason(vm); reason != "" {
+					checkError(config.Logrus, ms.SetMetric("perfCollectionSkippedReason", reason, metric.ATTRIBUTE))
+				} else {
+					addPerfMetrics(config, e, ms, entityTypeVm, dc.GetPerfMetrics(vm.Self))
 				}
 			}
 
 			// Snapshots
 			if vm.Snapshot != nil && vm.LayoutEx != nil && config.Args.EnableVsphereSnapshots {
 				sp := newSnapshotProcessor(config.Logrus, vm)
+				if config.IsVcenterAPIType {
+					sp.vcenterInstanceUUID = config.VMWareClient.ServiceContent.About.InstanceUuid
+				}
 				sp.processSnapshotTree(nil, vm.Snapshot.RootSnapshotList)
 				sp.createSnapshotSamples(e, entityName, vm.Snapshot.RootSnapshotList)
 			}
@@ -239,3 +278,33 @@ func createVirtualMachineSamples(config *config.Config) {
 		}
 	}
 }
+
+// addVMPlacement adds the DRS and HA overrides of the vm and the names of the groups and rules it is part of
+func addVMPlacement(config *config.Config, ms *metric.Set, p *model.VMPlacement) {
+	if p.DrsOverride != nil {
+		if p.DrsOverride.Enabled != nil {
+			checkError(config.Logrus, ms.SetMetric("drsOverride.enabled", strconv.FormatBool(*p.DrsOverride.Enabled), metric.ATTRIBUTE))
+		}
+		if p.DrsOverride.Behavior != "" {
+			checkError(config.Logrus, ms.SetMetric("drsOverride.behavior", string(p.DrsOverride.Behavior), metric.ATTRIBUTE))
+		}
+	}
+	if p.DasOverride != nil && p.DasOverride.DasSettings != nil {
+		settings := p.DasOverride.DasSettings
+		if settings.RestartPriority != "" {
+			checkError(config.Logrus, ms.SetMetric("haOverride.restartPriority", settings.RestartPriority, metric.ATTRIBUTE))
+		}
+		if settings.IsolationResponse != "" {
+			checkError(config.Logrus, ms.SetMetric("haOverride.isolationResponse", settings.IsolationResponse, metric.ATTRIBUTE))
+		}
+	}
+	if len(p.VmGroups) > 0 {
+		checkError(config.Logrus, ms.SetMetric("vmGroupNameList", strings.Join(p.VmGroups, "|"), metric.ATTRIBUTE))
+	}
+	if len(p.VmHostRules) > 0 {
+		checkError(config.Logrus, ms.SetMetric("vmHostRuleNameList", strings.Join(p.VmHostRules, "|"), metric.ATTRIBUTE))
+	}
+	if len(p.VmVmRules) > 0 {
+		checkError(config.Logrus, ms.SetMetric("vmVmRuleNameList", strings.Join(p.VmVmRules, "|"), metric.ATTRIBUTE))
+	}
+}
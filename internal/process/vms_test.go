@@ -11,10 +11,13 @@ import (
 	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func Test_createVirtualMachineSamples_HasIpAddresses(t *testing.T) {
@@ -55,3 +58,135 @@ func getDatacenter(ctx context.Context, vm *view.Manager) *model.Datacenter {
 	_ = cv.Retrieve(ctx, []string{"Datacenter"}, []string{"name"}, &datacenters)
 	return model.NewDatacenter(&datacenters[0])
 }
+
+func Test_createVirtualMachineSamples_HasCustomAttributes(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		assert.NoError(t, err)
+		vm := view.NewManager(vc)
+
+		fields, err := object.GetCustomFieldsManager(vc)
+		assert.NoError(t, err)
+		owner, err := fields.Add(ctx, "owner", "VirtualMachine", nil, nil)
+		assert.NoError(t, err)
+		tagged, err := find.NewFinder(vc).VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+		assert.NoError(t, err)
+		assert.NoError(t, fields.Set(ctx, tagged.Reference(), owner.Key, "team-a"))
+
+		// given
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Args.EnableCustomAttributes = true
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+
+		// when
+		collect.Hosts(cfg)
+		collect.VirtualMachines(cfg)
+		createVirtualMachineSamples(cfg)
+
+		// then
+		found := false
+		for _, e := range cfg.Integration.Entities {
+			if e.Metrics[0].Metrics["vmConfigName"] != "DC0_H0_VM0" {
+				assert.NotContains(t, e.Metrics[0].Metrics, "customAttribute.owner")
+				continue
+			}
+			found = true
+			assert.Equal(t, "team-a", e.Metrics[0].Metrics["customAttribute.owner"])
+			item, ok := e.Inventory.Item("tags")
+			assert.True(t, ok)
+			assert.Equal(t, "team-a", item["customAttribute.owner"])
+		}
+		assert.True(t, found)
+		return nil
+	})
+}
+
+func Test_createVirtualMachineSamples_HasAllocationAndPlacement(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		assert.NoError(t, err)
+		vm := view.NewManager(vc)
+
+		finder := find.NewFinder(vc)
+		limited, err := finder.VirtualMachine(ctx, "/DC0/vm/DC0_C0_RP0_VM0")
+		assert.NoError(t, err)
+		other, err := finder.VirtualMachine(ctx, "/DC0/vm/DC0_C0_RP0_VM1")
+		assert.NoError(t, err)
+		cluster, err := finder.ClusterComputeResource(ctx, "/DC0/host/DC0_C0")
+		assert.NoError(t, err)
+
+		limit := int64(1024)
+		hotAdd := true
+		task, err := limited.Reconfigure(ctx, types.VirtualMachineConfigSpec{
+			MemoryAllocation:    &types.ResourceAllocationInfo{Limit: &limit},
+			MemoryHotAddEnabled: &hotAdd,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, task.Wait(ctx))
+
+		enabled := false
+		spec := &types.ClusterConfigSpecEx{
+			DrsVmConfigSpec: []types.ClusterDrsVmConfigSpec{{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info:            &types.ClusterDrsVmConfigInfo{Key: limited.Reference(), Enabled: &enabled, Behavior: types.DrsBehaviorManual},
+			}},
+			DasVmConfigSpec: []types.ClusterDasVmConfigSpec{{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info: &types.ClusterDasVmConfigInfo{Key: limited.Reference(), DasSettings: &types.ClusterDasVmSettings{
+					RestartPriority: string(types.ClusterDasVmSettingsRestartPriorityHigh),
+				}},
+			}},
+			GroupSpec: []types.ClusterGroupSpec{{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info:            &types.ClusterVmGroup{ClusterGroupInfo: types.ClusterGroupInfo{Name: "db"}, Vm: []types.ManagedObjectReference{limited.Reference()}},
+			}, {
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info:            &types.ClusterHostGroup{ClusterGroupInfo: types.ClusterGroupInfo{Name: "db-hosts"}},
+			}},
+			RulesSpec: []types.ClusterRuleSpec{{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info:            &types.ClusterVmHostRuleInfo{ClusterRuleInfo: types.ClusterRuleInfo{Name: "db-on-db-hosts"}, VmGroupName: "db", AffineHostGroupName: "db-hosts"},
+			}, {
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info:            &types.ClusterAntiAffinityRuleSpec{ClusterRuleInfo: types.ClusterRuleInfo{Name: "spread"}, Vm: []types.ManagedObjectReference{limited.Reference(), other.Reference()}},
+			}},
+		}
+		task, err = cluster.Reconfigure(ctx, spec, true)
+		assert.NoError(t, err)
+		assert.NoError(t, task.Wait(ctx))
+
+		// given
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+
+		// when
+		collect.Hosts(cfg)
+		collect.Clusters(cfg)
+		collect.VirtualMachines(cfg)
+		createVirtualMachineSamples(cfg)
+
+		// then
+		samples := map[string]map[string]interface{}{}
+		for _, e := range cfg.Integration.Entities {
+			samples[e.Metrics[0].Metrics["vmConfigName"].(string)] = e.Metrics[0].Metrics
+		}
+		limitedSample := samples["DC0_C0_RP0_VM0"]
+		assert.Equal(t, float64(1024), limitedSample["mem.limitMB"])
+		assert.Equal(t, "true", limitedSample["mem.hotAddEnabled"])
+		assert.Contains(t, limitedSample, "cpu.sharesLevel")
+		assert.Equal(t, "false", limitedSample["drsOverride.enabled"])
+		assert.Equal(t, "manual", limitedSample["drsOverride.behavior"])
+		assert.Equal(t, "high", limitedSample["haOverride.restartPriority"])
+		assert.Equal(t, "db", limitedSample["vmGroupNameList"])
+		assert.Equal(t, "db-on-db-hosts", limitedSample["vmHostRuleNameList"])
+		assert.Equal(t, "spread", limitedSample["vmVmRuleNameList"])
+
+		otherSample := samples["DC0_C0_RP0_VM1"]
+		assert.Equal(t, "spread", otherSample["vmVmRuleNameList"])
+		assert.NotContains(t, otherSample, "vmGroupNameList")
+		assert.NotContains(t, otherSample, "drsOverride.enabled")
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// vSAN health status values
+const (
+	vsanHealthGreen  = "green"
+	vsanHealthYellow = "yellow"
+	vsanHealthRed    = "red"
+)
+
+// createVsanClusterSample adds the vSAN sample to the cluster entity
+func createVsanClusterSample(config *config.Config, e *integration.Entity, dc *model.Datacenter, cluster *mo.ClusterComputeResource, vsanCluster *model.VsanCluster) {
+	ms := newMetricSet(config, e, "VSphere"+sampleTypeVsanCluster+"Sample")
+
+	if config.Args.DatacenterLocation != "" {
+		checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+	}
+	checkError(config.Logrus, ms.SetMetric("datacenterName", dc.Datacenter.Name, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("clusterName", cluster.Name, metric.ATTRIBUTE))
+
+	if cfg := vsanCluster.Config; cfg != nil && cfg.DataEfficiencyConfig != nil {
+		checkError(config.Logrus, ms.SetMetric("dedupEnabled", strconv.FormatBool(cfg.DataEfficiencyConfig.DedupEnabled), metric.ATTRIBUTE))
+		if cfg.DataEfficiencyConfig.CompressionEnabled != nil {
+			checkError(config.Logrus, ms.SetMetric("compressionEnabled", strconv.FormatBool(*cfg.DataEfficiencyConfig.CompressionEnabled), metric.ATTRIBUTE))
+		}
+	}
+
+	// Capacity
+	if space := vsanCluster.SpaceUsage; space != nil {
+		checkError(config.Logrus, ms.SetMetric("capacity.total", float64(space.TotalCapacityB)/(1<<30), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("capacity.used", float64(space.TotalCapacityB-space.FreeCapacityB)/(1<<30), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("capacity.free", float64(space.FreeCapacityB)/(1<<30), metric.GAUGE))
+
+		// savings are the logical space written by the objects that does not use physical space after dedupe and compression
+		// physical usage can exceed the logical one because of the dedupe metadata, in that case there are no savings
+		if ec := space.EfficientCapacity; ec != nil && ec.PhysicalCapacityUsed > 0 {
+			savings := max(ec.LogicalCapacityUsed-ec.PhysicalCapacityUsed, 0)
+			checkError(config.Logrus, ms.SetMetric("dedupCompression.savings", float64(savings)/(1<<30), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("dedupCompression.ratio", float64(ec.LogicalCapacityUsed)/float64(ec.PhysicalCapacityUsed), metric.GAUGE))
+		}
+	}
+
+	// Resync
+	if resync := vsanCluster.Resync; resync != nil {
+		checkError(config.Logrus, ms.SetMetric("resync.bytesRemaining", resync.TotalBytesToSync, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("resync.objectsRemaining", resync.TotalObjectsToSync, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("resync.etaSeconds", resync.TotalRecoveryETA, metric.GAUGE))
+	}
+
+	checkError(config.Logrus, ms.SetMetric("diskGroups", vsanDiskGroups(dc, cluster), metric.GAUGE))
+
+	// Health checks
+	if health := vsanCluster.Health; health != nil {
+		checkError(config.Logrus, ms.SetMetric("health.overallStatus", health.OverallHealth, metric.ATTRIBUTE))
+		if health.OverallHealthDescription != "" {
+			checkError(config.Logrus, ms.SetMetric("health.overallDescription", health.OverallHealthDescription, metric.ATTRIBUTE))
+		}
+
+		testsByStatus := map[string]int{}
+		failedTests := ""
+		for _, group := range health.Groups {
+			for _, test := range group.GroupTests {
+				testsByStatus[test.TestHealth]++
+				if test.TestHealth == vsanHealthYellow || test.TestHealth == vsanHealthRed {
+					failedTests += group.GroupName + ":" + test.TestName + "|"
+				}
+			}
+		}
+		checkError(config.Logrus, ms.SetMetric("health.tests.green", testsByStatus[vsanHealthGreen], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("health.tests.yellow", testsByStatus[vsanHealthYellow], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("health.tests.red", testsByStatus[vsanHealthRed], metric.GAUGE))
+		failedTests = strings.TrimSuffix(failedTests, "|")
+		if failedTests != "" {
+			checkError(config.Logrus, ms.SetMetric("health.failedTests", failedTests, metric.ATTRIBUTE))
+		}
+	}
+
+	// Performance service metrics
+	for label, value := range vsanCluster.PerfMetrics {
+		checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+label, value, metric.GAUGE))
+	}
+}
+
+// vsanDiskGroups counts the disk groups claimed by vSAN in the hosts of the cluster
+func vsanDiskGroups(dc *model.Datacenter, cluster *mo.ClusterComputeResource) int {
+	diskGroups := 0
+	for _, hr := range cluster.Host {
+		h, ok := dc.Hosts[hr]
+		if !ok || h.Config == nil || h.Config.VsanHostConfig == nil || h.Config.VsanHostConfig.StorageInfo == nil {
+			continue
+		}
+		diskGroups += len(h.Config.VsanHostConfig.StorageInfo.DiskMapping)
+	}
+	return diskGroups
+}
@@ -0,0 +1,116 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+func Test_createClusterSamples_WithVsan(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		// given
+		vm := view.NewManager(vc)
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Integration, _ = integration.New("test", "dev")
+		dc := getDatacenter(ctx, vm)
+		cfg.Datacenters = append(cfg.Datacenters, dc)
+		collect.Clusters(cfg)
+		collect.Hosts(cfg)
+
+		var cluster types.ManagedObjectReference
+		for ref := range dc.Clusters {
+			cluster = ref
+		}
+		for _, hr := range dc.Clusters[cluster].Host {
+			dc.Hosts[hr].Config.VsanHostConfig = &types.VsanHostConfigInfo{
+				StorageInfo: &types.VsanHostConfigInfoStorageInfo{
+					DiskMapping: []types.VsanHostDiskMapping{{}, {}},
+				},
+			}
+		}
+		enabled := true
+		dc.VsanClusters[cluster] = &model.VsanCluster{
+			Config: &vsantypes.VsanConfigInfoEx{
+				VsanClusterConfigInfo: vsantypes.VsanClusterConfigInfo{Enabled: &enabled},
+				DataEfficiencyConfig:  &vsantypes.VsanDataEfficiencyConfig{DedupEnabled: true},
+			},
+			SpaceUsage: &vsantypes.VsanSpaceUsage{
+				TotalCapacityB: 10 << 30,
+				FreeCapacityB:  4 << 30,
+				EfficientCapacity: &vsantypes.VimVsanDataEfficiencyCapacityState{
+					LogicalCapacityUsed:  9 << 30,
+					PhysicalCapacityUsed: 3 << 30,
+				},
+			},
+			Resync: &vsantypes.VsanHostVsanObjectSyncQueryResult{TotalBytesToSync: 1024, TotalObjectsToSync: 2},
+			Health: &vsantypes.VsanClusterHealthSummary{
+				OverallHealth: "yellow",
+				Groups: []vsantypes.VsanClusterHealthGroup{{
+					GroupName: "Network",
+					GroupTests: []vsantypes.VsanClusterHealthTest{
+						{TestName: "MTU check", TestHealth: "yellow"},
+						{TestName: "Host connectivity", TestHealth: "green"},
+					},
+				}},
+			},
+			PerfMetrics: map[string]float64{"iopsRead": 150},
+		}
+
+		// when
+		createClusterSamples(cfg)
+
+		// then
+		var vsanSample map[string]interface{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] == "VSphereVsanClusterSample" {
+					vsanSample = ms.Metrics
+				}
+			}
+		}
+		require.NotNil(t, vsanSample)
+		assert.Equal(t, dc.Clusters[cluster].Name, vsanSample["clusterName"])
+		assert.Equal(t, "true", vsanSample["dedupEnabled"])
+		assert.Equal(t, float64(10), vsanSample["capacity.total"])
+		assert.Equal(t, float64(6), vsanSample["capacity.used"])
+		assert.Equal(t, float64(4), vsanSample["capacity.free"])
+		assert.Equal(t, float64(6), vsanSample["dedupCompression.savings"])
+		assert.Equal(t, float64(3), vsanSample["dedupCompression.ratio"])
+		assert.Equal(t, float64(1024), vsanSample["resync.bytesRemaining"])
+		assert.Equal(t, float64(2*len(dc.Clusters[cluster].Host)), vsanSample["diskGroups"])
+		assert.Equal(t, "yellow", vsanSample["health.overallStatus"])
+		assert.Equal(t, float64(1), vsanSample["health.tests.yellow"])
+		assert.Equal(t, float64(1), vsanSample["health.tests.green"])
+		assert.Equal(t, "Network:MTU check", vsanSample["health.failedTests"])
+		assert.Equal(t, float64(150), vsanSample["perf.iopsRead"])
+
+		// physical usage above the logical one does not report negative savings
+		dc.VsanClusters[cluster].SpaceUsage.EfficientCapacity.LogicalCapacityUsed = 2 << 30
+		cfg.Integration, _ = integration.New("test", "dev")
+		createClusterSamples(cfg)
+		for _, e := range cfg.Integration.Entities {
+			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] == "VSphereVsanClusterSample" {
+					assert.Equal(t, float64(0), ms.Metrics["dedupCompression.savings"])
+				}
+			}
+		}
+		return nil
+	})
+}
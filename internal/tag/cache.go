@@ -0,0 +1,90 @@
+package tag
+
+import (
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+)
+
+// tagCache keeps tag definitions and the tags attached to each object between executions, since fetching them
+// from the vCenter on every execution is expensive. Attachments are cached per object, so on each execution
+// they are fetched only for the objects that are new or whose entry expired.
+type tagCache struct {
+	store  persist.Storer
+	ttl    time.Duration
+	prefix string
+}
+
+// newTagCache returns a cache for the tags of the vCenter instance. If store is nil tags are never cached.
+func newTagCache(store persist.Storer, ttl time.Duration, instanceUUID string) *tagCache {
+	if store == nil {
+		return nil
+	}
+	return &tagCache{
+		store:  store,
+		ttl:    ttl,
+		prefix: "tags_" + instanceUUID + "_",
+	}
+}
+
+// loadDefinitions returns the cached tags by id, the second value is false if they are missing or expired
+func (tc *tagCache) loadDefinitions() (TagsByID, bool) {
+	if tc == nil {
+		return nil, false
+	}
+
+	var definitions TagsByID
+	if !tc.get(tc.prefix+"definitions", &definitions) || len(definitions) == 0 {
+		return nil, false
+	}
+	return definitions, true
+}
+
+func (tc *tagCache) saveDefinitions(definitions TagsByID) {
+	if tc == nil {
+		return
+	}
+	tc.store.Set(tc.prefix+"definitions", definitions)
+}
+
+// loadAttachments returns the ids of the tags attached to the object, the second value is false if they are
+// missing or expired
+func (tc *tagCache) loadAttachments(ref mor) ([]string, bool) {
+	if tc == nil {
+		return nil, false
+	}
+
+	var tagIDs []string
+	if !tc.get(tc.attachmentsKey(ref), &tagIDs) {
+		return nil, false
+	}
+	return tagIDs, true
+}
+
+func (tc *tagCache) saveAttachments(ref mor, tagIDs []string) {
+	if tc == nil {
+		return
+	}
+	if tagIDs == nil {
+		// objects without tags are cached as well, so they are not fetched again until the entry expires
+		tagIDs = []string{}
+	}
+	tc.store.Set(tc.attachmentsKey(ref), tagIDs)
+}
+
+func (tc *tagCache) attachmentsKey(ref mor) string {
+	return tc.prefix + ref.Type + "_" + ref.Value
+}
+
+func (tc *tagCache) get(key string, valuePtr interface{}) bool {
+	ts, err := tc.store.Get(key, valuePtr)
+	return err == nil && time.Since(time.Unix(ts, 0)) <= tc.ttl
+}
+
+// save persists the cache, entries older than the ttl are discarded by the store
+func (tc *tagCache) save() error {
+	if tc == nil {
+		return nil
+	}
+	return tc.store.Save()
+}
@@ -0,0 +1,92 @@
+package tag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+func Test_TagCache_ReusesDefinitionsAndAttachments(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+		m := tags.NewManager(c)
+
+		finder := find.NewFinder(vc)
+		vms, err := finder.VirtualMachineList(ctx, "*")
+		require.NoError(t, err)
+		tagged, untagged := vms[0].Reference(), vms[1].Reference()
+		objects := []mo.VirtualMachine{
+			{ManagedEntity: mo.ManagedEntity{ExtensibleManagedObject: mo.ExtensibleManagedObject{Self: tagged}}},
+			{ManagedEntity: mo.ManagedEntity{ExtensibleManagedObject: mo.ExtensibleManagedObject{Self: untagged}}},
+		}
+
+		categoryID, err := m.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "MULTIPLE"})
+		require.NoError(t, err)
+		prodID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "prod"})
+		require.NoError(t, err)
+		require.NoError(t, m.AttachTag(ctx, prodID, tagged))
+
+		store := persist.NewInMemoryStore()
+		first := NewCollector(m, logrus.StandardLogger())
+		first.SetCache(store, time.Hour, "vcenter-1")
+		require.NoError(t, first.BuildTagCache())
+		_, err = first.FetchTagsForObjects(objects)
+		require.NoError(t, err)
+		require.NoError(t, first.SaveCache())
+
+		// changes done after the first execution are not seen until the entries expire
+		testID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "test"})
+		require.NoError(t, err)
+		require.NoError(t, m.AttachTag(ctx, testID, untagged))
+		require.NoError(t, m.AttachTag(ctx, prodID, untagged))
+
+		second := NewCollector(m, logrus.StandardLogger())
+		second.SetCache(store, time.Hour, "vcenter-1")
+		require.NoError(t, second.BuildTagCache())
+		assert.Len(t, second.tagByIDCache, 1)
+		_, err = second.FetchTagsForObjects(objects)
+		require.NoError(t, err)
+		assert.Equal(t, []Tag{{Category: "env", Name: "prod"}}, second.GetTagsForObject(tagged))
+		assert.Empty(t, second.GetTagsForObject(untagged), "objects without tags are cached too")
+
+		// tags attached to new objects and missing from the cached definitions trigger a refresh of the definitions
+		added := vms[2].Reference()
+		require.NoError(t, m.AttachTag(ctx, testID, added))
+		_, err = second.FetchTagsForObjects([]mo.VirtualMachine{
+			{ManagedEntity: mo.ManagedEntity{ExtensibleManagedObject: mo.ExtensibleManagedObject{Self: added}}},
+		})
+		require.NoError(t, err)
+		assert.Len(t, second.tagByIDCache, 2)
+		assert.Equal(t, []Tag{{Category: "env", Name: "test"}}, second.GetTagsForObject(added))
+
+		// entries of other vCenters are not used
+		other := NewCollector(m, logrus.StandardLogger())
+		other.SetCache(store, time.Hour, "vcenter-2")
+		require.NoError(t, other.BuildTagCache())
+		assert.Len(t, other.tagByIDCache, 2)
+		_, err = other.FetchTagsForObjects(objects)
+		require.NoError(t, err)
+		assert.Equal(t, "prod|test", other.GetTagsByCategories(untagged)["env"])
+
+		// expired entries are fetched again
+		expired := NewCollector(m, logrus.StandardLogger())
+		expired.SetCache(store, 0, "vcenter-1")
+		require.NoError(t, expired.BuildTagCache())
+		assert.Len(t, expired.tagByIDCache, 2)
+		_, err = expired.FetchTagsForObjects(objects)
+		require.NoError(t, err)
+		assert.Equal(t, "prod|test", expired.GetTagsByCategories(untagged)["env"])
+	})
+}
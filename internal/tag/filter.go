@@ -0,0 +1,329 @@
+package tag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// filterExpr is a boolean expression evaluated against the tags attached to an object.
+//
+// The expression language supports:
+//   - terms in the form category=value, where value can be a glob pattern using * and ?, es: env=prod*, backup=*
+//   - the AND, OR and NOT operators, case insensitive, NOT having the highest precedence and OR the lowest
+//   - parentheses to group expressions
+//   - values containing spaces between double quotes, es: owner="team a"
+//
+// Terms not separated by an operator are evaluated as OR, so `region=eu env=test` is equivalent to
+// `region=eu OR env=test`.
+type filterExpr interface {
+	match(objectTags []Tag) bool
+	// candidates returns the objects that can match the expression given the objects attached to each term.
+	// The boolean is false if the expression can match objects having no tag attached, es: NOT env=prod
+	candidates(attached func(t tagTerm) map[mor]bool) (map[mor]bool, bool)
+}
+
+// tagTerm matches objects having at least a tag of the category whose name matches the pattern
+type tagTerm struct {
+	category string
+	pattern  *regexp.Regexp
+}
+
+func (t tagTerm) match(objectTags []Tag) bool {
+	for _, ot := range objectTags {
+		if ot.Category == t.category && t.pattern.MatchString(ot.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tagTerm) candidates(attached func(t tagTerm) map[mor]bool) (map[mor]bool, bool) {
+	// custom attributes are not vAPI tags, the objects having them cannot be listed up front
+	if strings.HasPrefix(t.category, CustomAttributePrefix) {
+		return nil, false
+	}
+	return attached(t), true
+}
+
+type notExpr struct {
+	expr filterExpr
+}
+
+func (n notExpr) match(objectTags []Tag) bool {
+	return !n.expr.match(objectTags)
+}
+
+func (n notExpr) candidates(func(t tagTerm) map[mor]bool) (map[mor]bool, bool) {
+	return nil, false
+}
+
+type andExpr []filterExpr
+
+func (a andExpr) match(objectTags []Tag) bool {
+	for _, expr := range a {
+		if !expr.match(objectTags) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a andExpr) candidates(attached func(t tagTerm) map[mor]bool) (map[mor]bool, bool) {
+	var result map[mor]bool
+	bounded := false
+	for _, expr := range a {
+		objects, ok := expr.candidates(attached)
+		if !ok {
+			continue
+		}
+		if !bounded {
+			result, bounded = objects, true
+			continue
+		}
+		intersection := map[mor]bool{}
+		for ref := range result {
+			if objects[ref] {
+				intersection[ref] = true
+			}
+		}
+		result = intersection
+	}
+	return result, bounded
+}
+
+type orExpr []filterExpr
+
+func (o orExpr) match(objectTags []Tag) bool {
+	for _, expr := range o {
+		if expr.match(objectTags) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o orExpr) candidates(attached func(t tagTerm) map[mor]bool) (map[mor]bool, bool) {
+	result := map[mor]bool{}
+	for _, expr := range o {
+		objects, ok := expr.candidates(attached)
+		if !ok {
+			return nil, false
+		}
+		for ref := range objects {
+			result[ref] = true
+		}
+	}
+	return result, true
+}
+
+const (
+	opAnd = "AND"
+	opOr  = "OR"
+	opNot = "NOT"
+)
+
+// parseFilterExpression parses a filter expression, it returns nil if the expression is empty
+func parseFilterExpression(expression string) (filterExpr, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &filterParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in tag filter", tok)
+	}
+	return expr, nil
+}
+
+// isLegacyFilterExpression returns true if the expression is a plain list of terms, having no operators,
+// parentheses or quotes
+func isLegacyFilterExpression(expression string) bool {
+	if strings.ContainsAny(expression, `()"`) {
+		return false
+	}
+	for _, tok := range strings.Fields(expression) {
+		if isOperator(tok, opAnd) || isOperator(tok, opOr) || isOperator(tok, opNot) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLegacyFilterExpression parses a plain list of terms evaluated as OR, invalid terms are logged and skipped.
+// If no term is valid the expression matches no object.
+func parseLegacyFilterExpression(expression string, logger logrus.FieldLogger) filterExpr {
+	exprs := orExpr{}
+	for _, tok := range strings.Fields(expression) {
+		term, err := parseTerm(tok)
+		if err != nil {
+			logger.WithField("tag", tok).Warn("invalid tag definition")
+			continue
+		}
+		exprs = append(exprs, term)
+	}
+	return exprs
+}
+
+// tokenize splits the expression in terms, operators and parentheses
+func tokenize(expression string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range expression {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in tag filter %q", expression)
+	}
+	flush()
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *filterParser) next() (string, bool) {
+	tok, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return tok, ok
+}
+
+func isOperator(tok, op string) bool {
+	return strings.EqualFold(tok, op)
+}
+
+// parseOr parses expressions separated by OR, or by nothing
+func (p *filterParser) parseOr() (filterExpr, error) {
+	var exprs orExpr
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		tok, ok := p.peek()
+		if !ok || tok == ")" {
+			break
+		}
+		if isOperator(tok, opOr) {
+			p.pos++
+		}
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+// parseAnd parses expressions separated by AND
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	var exprs andExpr
+	for {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		tok, ok := p.peek()
+		if !ok || !isOperator(tok, opAnd) {
+			break
+		}
+		p.pos++
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+// parseUnary parses a term, a negated expression or an expression between parentheses
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	tok, ok := p.next()
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("unexpected end of tag filter")
+	case isOperator(tok, opNot):
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	case tok == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.next(); !ok || tok != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis in tag filter")
+		}
+		return expr, nil
+	case tok == ")" || isOperator(tok, opAnd) || isOperator(tok, opOr):
+		return nil, fmt.Errorf("unexpected %q in tag filter", tok)
+	default:
+		return parseTerm(tok)
+	}
+}
+
+// parseTerm parses a category=value term, the value can contain the * and ? wildcards
+func parseTerm(tok string) (filterExpr, error) {
+	kv := strings.SplitN(tok, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+		return nil, fmt.Errorf("invalid tag %q, expected category=value", tok)
+	}
+
+	pattern := regexp.QuoteMeta(kv[1])
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag %q: %v", tok, err)
+	}
+	return tagTerm{category: kv[0], pattern: re}, nil
+}
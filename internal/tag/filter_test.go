@@ -0,0 +1,79 @@
+package tag
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/vapi/tags"
+)
+
+func Test_parseFilterExpression_Matches(t *testing.T) {
+	prodEu := []Tag{{Category: "env", Name: "prod"}, {Category: "region", Name: "eu-west"}}
+	prodBackup := []Tag{{Category: "env", Name: "prod"}, {Category: "backup", Name: "excluded"}}
+	test := []Tag{{Category: "env", Name: "test"}, {Category: "owner", Name: "team a"}}
+
+	tests := []struct {
+		expression string
+		want       []bool // prodEu, prodBackup, test
+	}{
+		{expression: "env=prod", want: []bool{true, true, false}},
+		{expression: "env=prod region=eu-west", want: []bool{true, true, false}},
+		{expression: "env=prod AND region=eu-west", want: []bool{true, false, false}},
+		{expression: "env=prod AND NOT backup=excluded", want: []bool{true, false, false}},
+		{expression: "env=prod and not backup=excluded", want: []bool{true, false, false}},
+		{expression: "NOT env=prod", want: []bool{false, false, true}},
+		{expression: "backup=*", want: []bool{false, true, false}},
+		{expression: "region=eu-*", want: []bool{true, false, false}},
+		{expression: "env=te?t", want: []bool{false, false, true}},
+		{expression: "env=t.st", want: []bool{false, false, false}},
+		{expression: `owner="team a"`, want: []bool{false, false, true}},
+		{expression: "env=test OR env=prod AND backup=*", want: []bool{false, true, true}},
+		{expression: "(env=test OR env=prod) AND NOT (backup=* OR region=eu*)", want: []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := parseFilterExpression(tt.expression)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want[0], expr.match(prodEu))
+			assert.Equal(t, tt.want[1], expr.match(prodBackup))
+			assert.Equal(t, tt.want[2], expr.match(test))
+		})
+	}
+}
+
+func Test_parseFilterExpression_Errors(t *testing.T) {
+	for _, expression := range []string{
+		"env",
+		"=prod",
+		"env=",
+		"env=prod AND",
+		"OR env=prod",
+		"NOT",
+		"(env=prod",
+		"env=prod)",
+		`owner="team a`,
+	} {
+		_, err := parseFilterExpression(expression)
+		assert.Error(t, err, expression)
+	}
+
+	expr, err := parseFilterExpression("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, expr)
+}
+
+func Test_MatchObjectTags_IncludeAndExclude(t *testing.T) {
+	collector := NewCollector(&tags.Manager{}, logrus.StandardLogger())
+	require.NoError(t, collector.ParseExcludeTagExpression("backup=excluded"))
+
+	assert.True(t, collector.matchTags(nil), "objects without tags are included if only the exclude filter is set")
+	assert.False(t, collector.matchTags([]Tag{{Category: "backup", Name: "excluded"}}))
+
+	require.NoError(t, collector.ParseFilterTagExpression("env=prod"))
+	assert.False(t, collector.matchTags(nil))
+	assert.True(t, collector.matchTags([]Tag{{Category: "env", Name: "prod"}}))
+	assert.False(t, collector.matchTags([]Tag{{Category: "env", Name: "prod"}, {Category: "backup", Name: "excluded"}}), "exclude filter has priority")
+}
@@ -0,0 +1,181 @@
+package tag
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+const (
+	typeFolder       = "Folder"
+	typeDatacenter   = "Datacenter"
+	typeCluster      = "ClusterComputeResource"
+	typeResourcePool = "ResourcePool"
+	typeVirtualApp   = "VirtualApp"
+	typeHost         = "HostSystem"
+)
+
+// BuildTagInheritance computes the tags that vms and hosts inherit from their ancestors:
+// vms inherit from their resource pools, host, cluster, folders and datacenter, while hosts inherit from their
+// cluster and datacenter. Inherited tags are used for filtering and reported together with the direct ones.
+// Each invocation of this func clears any previously computed inheritance.
+func (c *Collector) BuildTagInheritance(m *view.Manager, root mor) error {
+	ctx := context.Background()
+
+	cv, err := m.CreateContainerView(ctx, root, []string{"ManagedEntity"}, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := cv.Destroy(ctx)
+		if err != nil {
+			c.logger.WithError(err).Error("error while cleaning up tag inheritance container view")
+		}
+	}()
+
+	var entities []mo.ManagedEntity
+	err = cv.Retrieve(ctx, []string{"ManagedEntity"}, []string{"parent"}, &entities)
+	if err != nil {
+		return err
+	}
+	var vms []mo.VirtualMachine
+	err = cv.Retrieve(ctx, []string{"VirtualMachine"}, []string{"parent", "resourcePool", "runtime.host"}, &vms)
+	if err != nil {
+		return err
+	}
+
+	h := hierarchy{}
+	for _, e := range entities {
+		if e.Parent != nil {
+			h[e.Self] = *e.Parent
+		}
+	}
+
+	ancestorsByObject := map[mor][]mor{}
+	for _, e := range entities {
+		if e.Self.Type == typeHost {
+			ancestorsByObject[e.Self] = h.hostAncestors(e.Self)
+		}
+	}
+	for _, vm := range vms {
+		ancestorsByObject[vm.Self] = h.vmAncestors(vm)
+	}
+
+	var ancestors []mo.Reference
+	added := map[mor]bool{}
+	for _, refs := range ancestorsByObject {
+		for _, ref := range refs {
+			if !added[ref] {
+				added[ref] = true
+				ancestors = append(ancestors, ref)
+			}
+		}
+	}
+	if len(ancestors) == 0 {
+		return nil
+	}
+
+	tagsByAncestor, err := c.getTags(ancestors)
+	if err != nil {
+		return err
+	}
+
+	inheritedTags := TagsByObject{}
+	for ref, refs := range ancestorsByObject {
+		for _, ancestor := range refs {
+			for _, t := range tagsByAncestor[ancestor] {
+				t.Inherited = true
+				inheritedTags[ref] = appendTag(inheritedTags[ref], t)
+			}
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.inheritedTagsByObject = inheritedTags
+	return nil
+}
+
+// hierarchy stores the parent of each managed entity
+type hierarchy map[mor]mor
+
+// datacenter returns the datacenter containing the object
+func (h hierarchy) datacenter(ref mor) (mor, bool) {
+	for {
+		parent, ok := h[ref]
+		if !ok {
+			return mor{}, false
+		}
+		if parent.Type == typeDatacenter {
+			return parent, true
+		}
+		ref = parent
+	}
+}
+
+func (h hierarchy) hostAncestors(host mor) []mor {
+	var ancestors []mor
+	if parent, ok := h[host]; ok && parent.Type == typeCluster {
+		ancestors = append(ancestors, parent)
+	}
+	if dc, ok := h.datacenter(host); ok {
+		ancestors = append(ancestors, dc)
+	}
+	return ancestors
+}
+
+func (h hierarchy) vmAncestors(vm mo.VirtualMachine) []mor {
+	var ancestors []mor
+
+	// resource pools up to the root one, whose parent is the cluster
+	if vm.ResourcePool != nil {
+		rp := *vm.ResourcePool
+		for rp.Type == typeResourcePool || rp.Type == typeVirtualApp {
+			ancestors = append(ancestors, rp)
+			parent, ok := h[rp]
+			if !ok {
+				break
+			}
+			rp = parent
+		}
+	}
+
+	if vm.Runtime.Host != nil {
+		ancestors = append(ancestors, h.hostAncestors(*vm.Runtime.Host)...)
+		ancestors = append(ancestors, *vm.Runtime.Host)
+	}
+
+	// the folder chain ends with the datacenter
+	if vm.Parent != nil {
+		folder := *vm.Parent
+		for folder.Type == typeFolder {
+			ancestors = append(ancestors, folder)
+			parent, ok := h[folder]
+			if !ok {
+				break
+			}
+			folder = parent
+		}
+	}
+	// vms in a virtual app have no folder
+	dc, ok := h.datacenter(vm.Self)
+	if !ok && vm.ResourcePool != nil {
+		dc, ok = h.datacenter(*vm.ResourcePool)
+	}
+	if ok {
+		ancestors = append(ancestors, dc)
+	}
+
+	return ancestors
+}
+
+// appendTag adds the tag if the slice does not contain one with the same category and name
+func appendTag(ts []Tag, t Tag) []Tag {
+	for _, existing := range ts {
+		if existing.Category == t.Category && existing.Name == t.Name {
+			return ts
+		}
+	}
+	return append(ts, t)
+}
@@ -0,0 +1,67 @@
+package tag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_BuildTagInheritance(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+		m := tags.NewManager(c)
+
+		finder := find.NewFinder(vc)
+		dc, err := finder.Datacenter(ctx, "DC0")
+		require.NoError(t, err)
+		finder.SetDatacenter(dc)
+		cluster, err := finder.ClusterComputeResource(ctx, "DC0_C0")
+		require.NoError(t, err)
+		folders, err := dc.Folders(ctx)
+		require.NoError(t, err)
+		clusterVM, err := finder.VirtualMachine(ctx, "DC0_C0_RP0_VM0")
+		require.NoError(t, err)
+		clusterHost, err := finder.HostSystem(ctx, "DC0_C0_H0")
+		require.NoError(t, err)
+		standaloneHost, err := finder.HostSystem(ctx, "DC0_H0")
+		require.NoError(t, err)
+
+		categoryID, err := m.CreateCategory(ctx, &tags.Category{Name: "team", Cardinality: "MULTIPLE"})
+		require.NoError(t, err)
+		attach := func(name string, ref interface{ Reference() mor }) {
+			tagID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: name})
+			require.NoError(t, err)
+			require.NoError(t, m.AttachTag(ctx, tagID, ref.Reference()))
+		}
+		attach("datacenter", dc)
+		attach("cluster", cluster)
+		attach("folder", folders.VmFolder)
+		attach("vm", clusterVM)
+
+		collector := NewCollector(m, logrus.StandardLogger())
+		require.NoError(t, collector.BuildTagCache())
+		collector.cacheTags(TagsByObject{clusterVM.Reference(): {{Category: "team", Name: "vm"}}})
+
+		require.NoError(t, collector.BuildTagInheritance(view.NewManager(vc), vc.ServiceContent.RootFolder))
+
+		assert.Equal(t, "cluster|datacenter|folder|vm", collector.GetTagsByCategories(clusterVM.Reference())["team"])
+		assert.Equal(t, "cluster|datacenter|folder", collector.GetInheritedTagsByCategories(clusterVM.Reference())["team"])
+		assert.Equal(t, "cluster|datacenter", collector.GetTagsByCategories(clusterHost.Reference())["team"], "hosts do not inherit from folders")
+		assert.Equal(t, "datacenter", collector.GetTagsByCategories(standaloneHost.Reference())["team"])
+
+		require.NoError(t, collector.ParseFilterTagExpression("team=cluster"))
+		assert.True(t, collector.MatchObjectTags(clusterVM.Reference()))
+		assert.True(t, collector.MatchObjectTags(clusterHost.Reference()))
+		assert.False(t, collector.MatchObjectTags(standaloneHost.Reference()))
+	})
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 	"github.com/sirupsen/logrus"
 	"github.com/vmware/govmomi/vapi/tags"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -18,6 +19,8 @@ type mor = types.ManagedObjectReference
 type Tag struct {
 	Name     string
 	Category string
+	// Inherited is true if the tag is attached to an ancestor of the object
+	Inherited bool
 }
 
 // TagsByID stores tags per tag id
@@ -26,6 +29,9 @@ type TagsByID map[string]Tag
 // TagsByID stores tags per object
 type TagsByObject = map[mor][]Tag
 
+// CustomAttributePrefix is the category of the custom attributes used in filter expressions, es: customAttribute.owner=team-a
+const CustomAttributePrefix = "customAttribute."
+
 // separate in chunks of 2000 objects following performance recommendation
 // https://www.vmware.com/content/dam/digitalmarketing/vmware/en/pdf/techpaper/performance/tagging-vsphere67-perf.pdf
 const maxBatchSize = 2000
@@ -36,36 +42,68 @@ type Collector struct {
 
 	tagByIDCache      TagsByID
 	tagsByObjectCache TagsByObject
-	filterTags        []Tag
-	mutex             *sync.Mutex
+	// tags inherited by each object from its ancestors, see BuildTagInheritance
+	inheritedTagsByObject TagsByObject
+	// custom attributes of each object, used only by the filters, see AddCustomAttributes
+	customAttributesByObject TagsByObject
+	includeFilter            filterExpr
+	excludeFilter            filterExpr
+	// objects that can match the include filter, see ResolveFilteredObjects
+	filteredObjects map[mor]bool
+	filterResolved  bool
+	// tags persisted between executions, nil if disabled, see SetCache
+	cache *tagCache
+	mutex *sync.Mutex
+
+	// true once the definitions have been fetched from the vCenter in this execution instead of the cache
+	definitionsFetched bool
 }
 
-// ParseFilterTagExpression converts a filter tag expression into a slice of Tag
-// example: tag=value tag1=value1 --> t:{c: tag, n: value}, t1:{c: tag1, n: value1}
-// each invocation of this function resets any previously created filter
-func (c *Collector) ParseFilterTagExpression(tagFilterExpression string) {
-	if len(tagFilterExpression) == 0 {
-		return
+// SetCache persists tag definitions and the tags attached to each object in the store for the ttl, so they are not
+// fetched again from the vCenter on the following executions. Entries are kept per vCenter instance.
+func (c *Collector) SetCache(store persist.Storer, ttl time.Duration, instanceUUID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cache = newTagCache(store, ttl, instanceUUID)
+}
+
+// SaveCache persists the cached tags, it does nothing if the cache is disabled
+func (c *Collector) SaveCache() error {
+	return c.cache.save()
+}
+
+// ParseFilterTagExpression parses the expression selecting the objects to include, see filterExpr for the syntax
+// example: env=prod AND NOT (backup=excluded OR team=qa*)
+// each invocation of this function resets any previously created filter.
+// Invalid terms of a plain list of category=value terms, the syntax supported before the boolean operators, are
+// logged and skipped instead of returning an error.
+func (c *Collector) ParseFilterTagExpression(tagFilterExpression string) error {
+	filter, err := parseFilterExpression(tagFilterExpression)
+	if err != nil && isLegacyFilterExpression(tagFilterExpression) {
+		filter, err = parseLegacyFilterExpression(tagFilterExpression, c.logger), nil
+	}
+	if err != nil {
+		return err
 	}
 
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	// clear previous filter tags if any
-	c.filterTags = nil
-
-	fields := strings.Fields(tagFilterExpression)
-	for _, t := range fields {
-		kv := strings.Split(t, "=")
-		if len(kv) != 2 {
-			c.logger.WithField("tag", t).Warn("invalid tag definition")
-			continue
-		}
+	c.includeFilter = filter
+	return nil
+}
 
-		c.filterTags = append(c.filterTags, Tag{
-			Category: kv[0],
-			Name:     kv[1],
-		})
+// ParseExcludeTagExpression parses the expression selecting the objects to exclude, see filterExpr for the syntax
+// each invocation of this function resets any previously created filter
+func (c *Collector) ParseExcludeTagExpression(tagFilterExpression string) error {
+	filter, err := parseFilterExpression(tagFilterExpression)
+	if err != nil {
+		return err
 	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.excludeFilter = filter
+	return nil
 }
 
 // BuildTagCache caches all tag and categories from vCenter and stores them for future reference
@@ -74,9 +112,16 @@ func (c *Collector) BuildTagCache() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	//clear previous cache if any
-	c.tagByIDCache = TagsByID{}
+	if definitions, ok := c.cache.loadDefinitions(); ok {
+		c.logger.WithField("tags", len(definitions)).Debug("using cached tag definitions")
+		c.tagByIDCache = definitions
+		return nil
+	}
+	return c.fetchDefinitions()
+}
 
+// fetchDefinitions fetches all tag and categories from vCenter, the caller must hold the mutex
+func (c *Collector) fetchDefinitions() error {
 	ctx := context.Background()
 
 	categories, err := c.tm.GetCategories(ctx)
@@ -92,11 +137,15 @@ func (c *Collector) BuildTagCache() error {
 	if err != nil {
 		return err
 	}
+	definitions := TagsByID{}
 	for _, t := range ts {
 		if category, ok := categoriesByID[t.CategoryID]; ok {
-			c.tagByIDCache[t.ID] = Tag{Name: t.Name, Category: category}
+			definitions[t.ID] = Tag{Name: t.Name, Category: category}
 		}
 	}
+	c.tagByIDCache = definitions
+	c.definitionsFetched = true
+	c.cache.saveDefinitions(c.tagByIDCache)
 	return nil
 }
 
@@ -105,28 +154,52 @@ func (c *Collector) GetTagByID(id string) Tag {
 	return c.tagByIDCache[id]
 }
 
-// GetTagsByCategories return a map of tags categories and the corresponding tags associated to the object
+// GetTagsByCategories return a map of tags categories and the corresponding tags associated to the object,
+// including the inherited ones
 func (c *Collector) GetTagsByCategories(ref mor) map[string]string {
-	tagsByCategory := make(map[string]string)
-
 	if c.tagsByObjectCache == nil {
 		c.logger.Fatal(">>>> tagsByObjectCache is nill")
 	}
-	if ts, ok := c.tagsByObjectCache[ref]; ok {
-		sort.Slice(ts, func(i, j int) bool {
-			return ts[i].Name < ts[j].Name
-		})
-		for _, t := range ts {
-			if _, ok := tagsByCategory[t.Category]; ok {
-				tagsByCategory[t.Category] = tagsByCategory[t.Category] + "|" + t.Name
-			} else {
-				tagsByCategory[t.Category] = t.Name
-			}
+	return tagsByCategory(c.objectTags(ref))
+}
+
+// GetInheritedTagsByCategories return a map of tags categories and the corresponding tags inherited by the object
+// from its ancestors
+func (c *Collector) GetInheritedTagsByCategories(ref mor) map[string]string {
+	return tagsByCategory(c.inheritedTagsByObject[ref])
+}
+
+func tagsByCategory(ts []Tag) map[string]string {
+	tagsByCategory := make(map[string]string)
+
+	sort.Slice(ts, func(i, j int) bool {
+		return ts[i].Name < ts[j].Name
+	})
+	for _, t := range ts {
+		if _, ok := tagsByCategory[t.Category]; ok {
+			tagsByCategory[t.Category] = tagsByCategory[t.Category] + "|" + t.Name
+		} else {
+			tagsByCategory[t.Category] = t.Name
 		}
 	}
 	return tagsByCategory
 }
 
+// objectTags returns the tags attached to the object followed by the inherited ones not attached directly
+func (c *Collector) objectTags(ref mor) []Tag {
+	inherited := c.inheritedTagsByObject[ref]
+	if len(inherited) == 0 {
+		return c.tagsByObjectCache[ref]
+	}
+
+	ts := make([]Tag, 0, len(c.tagsByObjectCache[ref])+len(inherited))
+	ts = append(ts, c.tagsByObjectCache[ref]...)
+	for _, t := range inherited {
+		ts = appendTag(ts, t)
+	}
+	return ts
+}
+
 // GetTagsForObject gets all tags for a object
 func (c *Collector) GetTagsForObject(or mor) []Tag {
 	return c.tagsByObjectCache[or]
@@ -164,6 +237,10 @@ func (c *Collector) FetchTagsForObjects(objectsSlice interface{}) (TagsByObject,
 		for _, o := range obs {
 			ref = append(ref, o.Self)
 		}
+	case []mo.StoragePod:
+		for _, o := range obs {
+			ref = append(ref, o.Self)
+		}
 	default:
 		return nil, fmt.Errorf("type unknown")
 	}
@@ -181,25 +258,124 @@ func (c *Collector) FetchTagsForObjects(objectsSlice interface{}) (TagsByObject,
 	return tagsByObject, nil
 }
 
-// MatchObjectTags checks if any tag in the resource tags matches any of the 'filterTags'
+// MatchObjectTags checks if the resource tags, including the inherited ones and the custom attributes, match the include
+// filter and do not match the exclude filter
 func (c *Collector) MatchObjectTags(resource mor) bool {
-	objectTags := c.tagsByObjectCache[resource.Reference()]
-	if len(objectTags) == 0 {
-		return false
+	ref := resource.Reference()
+	objectTags := c.objectTags(ref)
+	if attributes := c.customAttributes(ref); len(attributes) > 0 {
+		objectTags = append(append([]Tag{}, objectTags...), attributes...)
 	}
-
 	return c.matchTags(objectTags)
 }
 
+// AddCustomAttributes stores the custom attributes of an object so they can be used in the filters as tags having
+// category CustomAttributePrefix+name
+func (c *Collector) AddCustomAttributes(ref mor, attributes map[string]string) {
+	if len(attributes) == 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ts := make([]Tag, 0, len(attributes))
+	for name, value := range attributes {
+		ts = append(ts, Tag{Category: CustomAttributePrefix + name, Name: value})
+	}
+	c.customAttributesByObject[ref] = ts
+}
+
+func (c *Collector) customAttributes(ref mor) []Tag {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.customAttributesByObject[ref]
+}
+
 func (c *Collector) matchTags(objectTags []Tag) bool {
-	for _, ft := range c.filterTags {
-		for _, ot := range objectTags {
-			if ot.Category == ft.Category && ot.Name == ft.Name {
-				return true
+	if c.includeFilter != nil && !c.includeFilter.match(objectTags) {
+		return false
+	}
+	if c.excludeFilter != nil && c.excludeFilter.match(objectTags) {
+		return false
+	}
+	return true
+}
+
+// ResolveFilteredObjects lists the objects attached to the tags matching the terms of the include filter and
+// computes the set of objects that can match it. Collectors can then retrieve properties only for those objects
+// instead of every object in the inventory.
+// The set is not resolved if there is no include filter or if the filter can match objects without tags attached,
+// es: NOT env=prod. The tag cache must be built before calling this func.
+func (c *Collector) ResolveFilteredObjects() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.filteredObjects = nil
+	c.filterResolved = false
+	if c.includeFilter == nil {
+		return nil
+	}
+
+	var tagIDs []string
+	for id := range c.tagByIDCache {
+		tagIDs = append(tagIDs, id)
+	}
+	sort.Strings(tagIDs)
+
+	var termErr error
+	objectsByTagID := map[string][]mo.Reference{}
+	attached := func(t tagTerm) map[mor]bool {
+		var matching, missing []string
+		for _, id := range tagIDs {
+			tag := c.tagByIDCache[id]
+			if tag.Category != t.category || !t.pattern.MatchString(tag.Name) {
+				continue
+			}
+			matching = append(matching, id)
+			if _, ok := objectsByTagID[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+
+		if len(missing) > 0 {
+			attachedObjects, err := c.tm.ListAttachedObjectsOnTags(context.Background(), missing)
+			if err != nil {
+				termErr = fmt.Errorf("failed to list objects attached to tags:%v", err)
+			}
+			for _, id := range missing {
+				objectsByTagID[id] = nil
+			}
+			for _, ao := range attachedObjects {
+				objectsByTagID[ao.TagID] = append(objectsByTagID[ao.TagID], ao.ObjectIDs...)
 			}
 		}
+
+		objects := map[mor]bool{}
+		for _, id := range matching {
+			for _, o := range objectsByTagID[id] {
+				objects[o.Reference()] = true
+			}
+		}
+		return objects
 	}
-	return false
+
+	objects, bounded := c.includeFilter.candidates(attached)
+	if termErr != nil {
+		return termErr
+	}
+	if bounded {
+		c.filteredObjects = objects
+		c.filterResolved = true
+	}
+	return nil
+}
+
+// FilteredObjects returns the objects that can match the include filter. The boolean is false if the set was not
+// resolved, in that case every object has to be considered.
+func (c *Collector) FilteredObjects() (map[mor]bool, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.filteredObjects, c.filterResolved
 }
 
 // cache tags grouped by object reference
@@ -211,25 +387,60 @@ func (c *Collector) cacheTags(tagsByObject TagsByObject) {
 	}
 }
 
-// return all tags attached to objects in ref grouped by the object reference
+// return all tags attached to objects in ref grouped by the object reference.
+// When the cache is enabled, attachments are fetched only for the objects missing in the cache or expired.
 func (c *Collector) getTags(ref []mo.Reference) (TagsByObject, error) {
 	ctx := context.Background()
 
-	var attachedTags []tags.AttachedTags
-	for i := 0; i < len(ref); i += maxBatchSize {
-		batch := ref[i:min(i+maxBatchSize, len(ref))]
+	tagIDsByObject := make(map[mor][]string)
+	var missing []mo.Reference
+	for _, r := range ref {
+		if tagIDs, ok := c.cache.loadAttachments(r.Reference()); ok {
+			tagIDsByObject[r.Reference()] = tagIDs
+		} else {
+			missing = append(missing, r)
+		}
+	}
+	if c.cache != nil {
+		c.logger.WithField("cached", len(ref)-len(missing)).WithField("missing", len(missing)).Debug("fetching tags attached to objects")
+	}
+
+	for i := 0; i < len(missing); i += maxBatchSize {
+		batch := missing[i:min(i+maxBatchSize, len(missing))]
 
 		result, err := c.tm.ListAttachedTagsOnObjects(ctx, batch)
 		if err != nil {
 			return nil, fmt.Errorf("fail to get tags:%v", err)
 		}
-		attachedTags = append(attachedTags, result...)
+
+		fetched := make(map[mor][]string, len(batch))
+		for _, r := range batch {
+			fetched[r.Reference()] = nil
+		}
+		for _, attached := range result {
+			or := attached.ObjectID.Reference()
+			fetched[or] = append(fetched[or], attached.TagIDs...)
+		}
+		for or, tagIDs := range fetched {
+			c.cache.saveAttachments(or, tagIDs)
+			tagIDsByObject[or] = tagIDs
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// cached definitions miss the tags created after they were stored, so they are fetched again
+	if !c.definitionsFetched && c.hasUnknownTags(tagIDsByObject) {
+		c.logger.Debug("attached tags missing from the cached tag definitions, fetching them again")
+		if err := c.fetchDefinitions(); err != nil {
+			c.logger.WithError(err).Warn("failed to refresh tag definitions")
+		}
 	}
 
 	tagsByObject := make(map[mor][]Tag)
-	for _, tag := range attachedTags {
-		or := tag.ObjectID.Reference()
-		for _, tagID := range tag.TagIDs {
+	for or, tagIDs := range tagIDsByObject {
+		for _, tagID := range tagIDs {
 			if tag, ok := c.tagByIDCache[tagID]; ok {
 				tagsByObject[or] = append(tagsByObject[or], tag)
 			}
@@ -238,14 +449,26 @@ func (c *Collector) getTags(ref []mo.Reference) (TagsByObject, error) {
 	return tagsByObject, nil
 }
 
+// hasUnknownTags returns true if any of the tag ids is missing from the definitions, the caller must hold the mutex
+func (c *Collector) hasUnknownTags(tagIDsByObject map[mor][]string) bool {
+	for _, tagIDs := range tagIDsByObject {
+		for _, tagID := range tagIDs {
+			if _, ok := c.tagByIDCache[tagID]; !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func NewCollector(tagManager *tags.Manager, logger *logrus.Logger) *Collector {
 	return &Collector{
-		tm:                tagManager,
-		logger:            logger,
-		tagsByObjectCache: TagsByObject{},
-		tagByIDCache:      TagsByID{},
-		filterTags:        []Tag{},
-		mutex:             &sync.Mutex{},
+		tm:                       tagManager,
+		logger:                   logger,
+		tagsByObjectCache:        TagsByObject{},
+		customAttributesByObject: TagsByObject{},
+		tagByIDCache:             TagsByID{},
+		mutex:                    &sync.Mutex{},
 	}
 }
 
@@ -115,40 +115,56 @@ func Test_ParseTagFilterExpression_CreatesTagFilter(t *testing.T) {
 	collector := NewCollector(&tags.Manager{}, logrus.StandardLogger())
 
 	tests := []struct {
-		name string
-		args string
-		want []Tag
+		name    string
+		args    string
+		wantErr bool
+		matches []Tag
 	}{
 		{
-			name: "InvalidExpression",
-			args: "key value",
-			want: nil,
+			name:    "InvalidExpression",
+			args:    "key value",
+			matches: []Tag{},
+		},
+		{
+			name:    "InvalidExpression",
+			args:    "key:value",
+			matches: []Tag{},
+		},
+		{
+			name:    "InvalidTermIsSkipped",
+			args:    "key:value region=eu",
+			matches: []Tag{{Category: "region", Name: "eu"}},
 		},
 		{
-			name: "InvalidExpression",
-			args: "key:value",
-			want: nil,
+			name:    "InvalidBooleanExpression",
+			args:    "region=eu AND (key:value",
+			wantErr: true,
 		},
 		{
-			name: "SingleTag",
-			args: "region=eu",
-			want: []Tag{{Category: "region", Name: "eu"}},
+			name:    "SingleTag",
+			args:    "region=eu",
+			matches: []Tag{{Category: "region", Name: "eu"}},
 		},
 		{
-			name: "MultipleTags",
-			args: "region=eu env=test",
-			want: []Tag{{Category: "region", Name: "eu"}, {Category: "env", Name: "test"}},
+			name:    "MultipleTags",
+			args:    "region=eu env=test",
+			matches: []Tag{{Category: "env", Name: "test"}},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			// when
-			collector.ParseFilterTagExpression(tt.args)
+			err := collector.ParseFilterTagExpression(tt.args)
 
 			// then
-			assert.Equal(t, len(tt.want), len(collector.filterTags))
-			assert.EqualValues(t, tt.want, collector.filterTags)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.matches) > 0, collector.matchTags(tt.matches))
+			assert.False(t, collector.matchTags([]Tag{{Category: "region", Name: "asia"}}))
 		})
 	}
 }
@@ -200,3 +216,83 @@ func Test_MatchObjectsTags_ReturnsCorrectValue(t *testing.T) {
 		})
 	}
 }
+
+func Test_ResolveFilteredObjects(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		assert.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+		m := tags.NewManager(c)
+
+		finder := find.NewFinder(vc)
+		vms, err := finder.VirtualMachineList(ctx, "*")
+		assert.NoError(t, err)
+		hosts, err := finder.HostSystemList(ctx, "*")
+		assert.NoError(t, err)
+
+		categoryID, err := m.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "MULTIPLE"})
+		assert.NoError(t, err)
+		prodID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "prod"})
+		assert.NoError(t, err)
+		prodEuID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "prod-eu"})
+		assert.NoError(t, err)
+		testID, err := m.CreateTag(ctx, &tags.Tag{CategoryID: categoryID, Name: "test"})
+		assert.NoError(t, err)
+		assert.NoError(t, m.AttachTag(ctx, prodID, vms[0].Reference()))
+		assert.NoError(t, m.AttachTag(ctx, prodEuID, vms[1].Reference()))
+		assert.NoError(t, m.AttachTag(ctx, testID, vms[1].Reference()))
+		assert.NoError(t, m.AttachTag(ctx, testID, hosts[0].Reference()))
+
+		collector := NewCollector(m, logrus.StandardLogger())
+		assert.NoError(t, collector.BuildTagCache())
+
+		_, resolved := collector.FilteredObjects()
+		assert.False(t, resolved, "not resolved before ResolveFilteredObjects")
+
+		tests := []struct {
+			expression string
+			resolved   bool
+			expected   []mor
+		}{
+			{"", false, nil},
+			{"env=prod*", true, []mor{vms[0].Reference(), vms[1].Reference()}},
+			{"env=prod* AND env=test", true, []mor{vms[1].Reference()}},
+			{"env=prod OR env=test", true, []mor{vms[0].Reference(), vms[1].Reference(), hosts[0].Reference()}},
+			{"env=test AND NOT env=prod*", true, []mor{vms[1].Reference(), hosts[0].Reference()}},
+			{"env=missing", true, nil},
+			{"NOT env=test", false, nil},
+			{"env=prod OR NOT env=test", false, nil},
+		}
+		for _, tt := range tests {
+			assert.NoError(t, collector.ParseFilterTagExpression(tt.expression))
+			assert.NoError(t, collector.ResolveFilteredObjects())
+
+			objects, resolved := collector.FilteredObjects()
+			assert.Equal(t, tt.resolved, resolved, tt.expression)
+			assert.Len(t, objects, len(tt.expected), tt.expression)
+			for _, ref := range tt.expected {
+				assert.True(t, objects[ref], "%s: %s", tt.expression, ref)
+			}
+		}
+	})
+}
+
+func Test_MatchObjectTags_WithCustomAttributes(t *testing.T) {
+	collector := NewCollector(nil, logrus.StandardLogger())
+	vm := mor{Type: "VirtualMachine", Value: "vm-1"}
+	other := mor{Type: "VirtualMachine", Value: "vm-2"}
+	collector.cacheTags(TagsByObject{vm: {{Category: "env", Name: "prod"}}, other: {{Category: "env", Name: "prod"}}})
+	collector.AddCustomAttributes(vm, map[string]string{"owner": "team-a"})
+
+	assert.NoError(t, collector.ParseFilterTagExpression("env=prod AND customAttribute.owner=team-*"))
+	assert.True(t, collector.MatchObjectTags(vm))
+	assert.False(t, collector.MatchObjectTags(other))
+
+	// custom attributes are used only by the filters
+	assert.Equal(t, map[string]string{"env": "prod"}, collector.GetTagsByCategories(vm))
+
+	// objects having a custom attribute cannot be resolved up front
+	assert.NoError(t, collector.ParseFilterTagExpression("customAttribute.owner=team-a"))
+	assert.NoError(t, collector.ResolveFilteredObjects())
+	_, resolved := collector.FilteredObjects()
+	assert.False(t, resolved)
+}